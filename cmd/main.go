@@ -1,11 +1,16 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
+	_ "time/tzdata" // Translators' working hours are read in their own time zone
 	"translation-app-backend/internal/database"
 	"translation-app-backend/internal/handlers"
+	"translation-app-backend/internal/mailer"
+	"translation-app-backend/internal/payments"
 	"translation-app-backend/internal/routes"
+	"translation-app-backend/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -36,7 +41,20 @@ func main() {
 		log.Fatal("Failed to connect to database: ", err)
 	}
 
-	routes.SetupRoutes(app, db)
+	store, err := storage.New()
+	if err != nil {
+		log.Fatal("Failed to set up file storage: ", err)
+	}
+
+	// Without a payment provider customers pay by uploading a receipt
+	provider, err := payments.New()
+	if errors.Is(err, payments.ErrNotConfigured) {
+		log.Printf("Online payment disabled: %v", err)
+	} else if err != nil {
+		log.Fatal("Failed to set up payments: ", err)
+	}
+
+	routes.SetupRoutes(app, db, store, provider)
 
 	// Set up the cron job
 	c := cron.New()
@@ -44,6 +62,22 @@ func main() {
 	if err2 != nil {
 		panic(err)
 	}
+
+	_, err = c.AddFunc("@every 15m", func() { handlers.CheckDeadlines(db) })
+	if err != nil {
+		log.Fatal("Failed to schedule deadline checks: ", err)
+	}
+
+	// Notification emails are queued in the database and sent in the background
+	sender, err := mailer.NewSMTPSenderFromEnv()
+	if err != nil {
+		log.Printf("Email delivery disabled: %v", err)
+	} else {
+		deliverEmails := cron.FuncJob(func() { handlers.DeliverQueuedEmails(db, sender) })
+		if _, err := c.AddJob("@every 1m", cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(deliverEmails)); err != nil {
+			log.Fatal("Failed to schedule email delivery: ", err)
+		}
+	}
 	c.Start()
 	defer c.Stop()
 
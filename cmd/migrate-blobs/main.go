@@ -0,0 +1,112 @@
+// Command migrate-blobs moves file contents that older versions stored in the
+// documents table into the configured blob store and records their keys.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"translation-app-backend/internal/database"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+// legacyColumn pairs a former []byte column with the columns that replace it
+type legacyColumn struct {
+	content  string
+	fileName string
+	key      string
+	prefix   string
+}
+
+var legacyColumns = []legacyColumn{
+	{content: "file_content", fileName: "file_name", key: "file_key", prefix: "documents"},
+	{content: "translated_file_content", fileName: "translated_file_name", key: "translated_file_key", prefix: "translations"},
+	{content: "payment_receipt_content", fileName: "payment_receipt_file_name", key: "payment_receipt_key", prefix: "receipts"},
+}
+
+func main() {
+	drop := flag.Bool("drop", false, "drop the legacy content columns once every row has been moved")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+
+	store, err := storage.New()
+	if err != nil {
+		log.Fatal("Failed to set up file storage: ", err)
+	}
+
+	ctx := context.Background()
+	for _, column := range legacyColumns {
+		if !db.Migrator().HasColumn(&models.Document{}, column.content) {
+			log.Printf("Column %s does not exist, skipping", column.content)
+			continue
+		}
+
+		moved, err := migrateColumn(ctx, db, store, column)
+		if err != nil {
+			log.Fatalf("Failed to migrate %s: %v", column.content, err)
+		}
+		log.Printf("Moved %d blobs out of %s", moved, column.content)
+
+		if *drop {
+			if err := db.Migrator().DropColumn(&models.Document{}, column.content); err != nil {
+				log.Fatalf("Failed to drop %s: %v", column.content, err)
+			}
+			log.Printf("Dropped column %s", column.content)
+		}
+	}
+}
+
+// migrateColumn copies one legacy column row by row so a single file is held in memory at a time
+func migrateColumn(ctx context.Context, db *gorm.DB, store storage.BlobStore, column legacyColumn) (int, error) {
+	var ids []uint
+	if err := db.Table("documents").
+		Where(column.content+" IS NOT NULL").
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, id := range ids {
+		var row struct {
+			Content  []byte
+			FileName string
+		}
+		if err := db.Table("documents").
+			Select(column.content+" AS content, "+column.fileName+" AS file_name").
+			Where("id = ?", id).
+			Take(&row).Error; err != nil {
+			return moved, err
+		}
+
+		key := storage.NewKey(column.prefix, row.FileName)
+		if err := store.Put(ctx, key, bytes.NewReader(row.Content), int64(len(row.Content)), ""); err != nil {
+			return moved, err
+		}
+
+		// Record the key and clear the bytes in one statement so reruns skip this row
+		if err := db.Table("documents").Where("id = ?", id).Updates(map[string]interface{}{
+			column.key:     key,
+			column.content: nil,
+		}).Error; err != nil {
+			store.Delete(ctx, key)
+			return moved, err
+		}
+		moved++
+	}
+
+	return moved, nil
+}
@@ -1,11 +1,90 @@
 package database
 
 import (
+	"log"
 	"translation-app-backend/internal/models"
 
 	"gorm.io/gorm"
 )
 
 func Migrate(db *gorm.DB) {
-	db.AutoMigrate(&models.User{}, &models.Notification{}, &models.Document{}, &models.Discussion{}, &models.Rating{}, &models.Mail{},&models.Settings{})
-}
\ No newline at end of file
+	// Accounts created before email verification existed are trusted
+	verifiedExisted := db.Migrator().HasColumn(&models.User{}, "Verified")
+	// Translators registered before vetting existed were already working
+	vettingExisted := db.Migrator().HasColumn(&models.User{}, "VettingStatus")
+	// Versions recorded before verdicts existed were all delivered ones
+	verdictExisted := db.Migrator().HasColumn(&models.TranslationVersion{}, "Verdict")
+	// Quotes made before the tax rate was kept with them were taxed at the rate of the day
+	quoteTaxExisted := db.Migrator().HasColumn(&models.Document{}, "quote_tax_rate")
+
+	db.AutoMigrate(&models.User{}, &models.Notification{}, &models.Document{}, &models.Discussion{}, &models.Rating{}, &models.Mail{}, &models.Settings{}, &models.DocumentEvent{}, &models.PricingRule{}, &models.UrgencyTier{}, &models.AssignmentAttempt{}, &models.AdminSubscription{}, &models.EmailOutbox{}, &models.Session{}, &models.UserToken{}, &models.TranslatorCredential{}, &models.TestTranslation{}, &models.TimeOff{}, &models.WorkingHours{}, &models.TranslationVersion{}, &models.RevisionRequest{}, &models.Cancellation{}, &models.Refund{}, &models.PaymentIntent{}, &models.Invoice{}, &models.InvoiceSequence{}, &models.LedgerEntry{}, &models.Payout{}, &models.BankAccount{}, &models.Coupon{}, &models.CouponRedemption{})
+
+	if !verifiedExisted {
+		if err := db.Model(&models.User{}).Where("verified = ?", false).Update("verified", true).Error; err != nil {
+			log.Printf("Failed to mark existing users as verified: %v", err)
+		}
+	}
+
+	if !vettingExisted {
+		if err := db.Model(&models.User{}).Where("role = ?", models.RoleTranslator).Update("vetting_status", models.VettingApproved).Error; err != nil {
+			log.Printf("Failed to mark existing translators as vetted: %v", err)
+		}
+	}
+
+	if !verdictExisted {
+		if err := db.Model(&models.TranslationVersion{}).Where("delivered_at IS NOT NULL").Update("verdict", models.VersionApproved).Error; err != nil {
+			log.Printf("Failed to mark delivered translation versions as approved: %v", err)
+		}
+	}
+
+	if !quoteTaxExisted {
+		backfillQuoteTaxRates(db)
+	}
+
+	backfillDocumentStates(db)
+	seedUrgencyTiers(db)
+}
+
+// seedUrgencyTiers creates the default turnaround options on a fresh database
+func seedUrgencyTiers(db *gorm.DB) {
+	var count int64
+	if err := db.Model(&models.UrgencyTier{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+
+	tiers := []models.UrgencyTier{
+		{Code: models.UrgencyStandard, Name: "Standard", Multiplier: 1, TurnaroundDays: 7},
+		{Code: models.UrgencyExpress, Name: "Express", Multiplier: 1.5, TurnaroundDays: 3},
+		{Code: models.UrgencyUrgent, Name: "Urgent", Multiplier: 2, TurnaroundDays: 1},
+	}
+	if err := db.Create(&tiers).Error; err != nil {
+		log.Printf("Failed to seed urgency tiers: %v", err)
+	}
+}
+
+// backfillQuoteTaxRates keeps the rate already printed on a document's invoice, other quotes
+// take the current rate as they would have been invoiced at it
+func backfillQuoteTaxRates(db *gorm.DB) {
+	rate := gorm.Expr(`COALESCE(
+		(SELECT invoices.tax_rate FROM invoices WHERE invoices.document_id = documents.id AND invoices.deleted_at IS NULL ORDER BY invoices.id LIMIT 1),
+		(SELECT settings.tax_rate FROM settings WHERE settings.deleted_at IS NULL ORDER BY settings.id LIMIT 1),
+		0)`)
+	if err := db.Model(&models.Document{}).Where("quote_quoted_at IS NOT NULL").Update("quote_tax_rate", rate).Error; err != nil {
+		log.Printf("Failed to backfill quote tax rates: %v", err)
+	}
+}
+
+// backfillDocumentStates derives State for documents created before the lifecycle existed
+func backfillDocumentStates(db *gorm.DB) {
+	var documents []models.Document
+	if err := db.Where("state IS NULL OR state = ''").Find(&documents).Error; err != nil {
+		log.Printf("Failed to load documents without state: %v", err)
+		return
+	}
+
+	for _, document := range documents {
+		if err := db.Model(&document).UpdateColumn("state", document.LegacyState()).Error; err != nil {
+			log.Printf("Failed to backfill state for document ID %d: %v", document.ID, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"time"
+	"translation-app-backend/internal/mailer"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	passwordResetTTL = time.Hour
+)
+
+var errInvalidUserToken = errors.New("invalid or expired token")
+
+// signToken returns the signature stored for an emailed token, a leaked table alone
+// can't be used to verify accounts or reset passwords
+func signToken(token string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("SECRET")))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// issueUserToken creates a single-use token for a user. Earlier unused tokens with the
+// same purpose stop working.
+func issueUserToken(db *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: signToken(token),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	return token, err
+}
+
+// consumeUserToken marks a token as used and returns the user it was issued to
+func consumeUserToken(tx *gorm.DB, token string, purpose string) (uint, error) {
+	var userToken models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", signToken(token), purpose).First(&userToken).Error; err != nil {
+		return 0, errInvalidUserToken
+	}
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return 0, errInvalidUserToken
+	}
+
+	// Guard against the same token being used twice at the same time
+	result := tx.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", userToken.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errInvalidUserToken
+	}
+
+	return userToken.UserID, nil
+}
+
+// sendVerificationEmail mails a new email verification link to the user
+func sendVerificationEmail(db *gorm.DB, user models.User) error {
+	token, err := issueUserToken(db, user.ID, models.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	link := appURL() + "/verify-email?token=" + url.QueryEscape(token)
+	return queueEmail(db, user, models.TokenVerifyEmail, mailer.TemplateData{Link: link})
+}
+
+// VerifyEmail confirms the email address of the user the token was sent to
+func VerifyEmail(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("token")
+		if token == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "token is required"})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			userID, err := consumeUserToken(tx, token, models.TokenVerifyEmail)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&models.User{}).Where("id = ?", userID).Update("verified", true).Error
+		})
+		if errors.Is(err, errInvalidUserToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not verify email"})
+		}
+
+		return c.JSON(fiber.Map{"message": "email verified successfully"})
+	}
+}
+
+// ResendVerificationEmail sends a new verification link to the logged in user
+func ResendVerificationEmail(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+
+		if user.Verified {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "email is already verified"})
+		}
+
+		if err := sendVerificationEmail(db, user); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not send verification email"})
+		}
+
+		return c.JSON(fiber.Map{"message": "verification email sent"})
+	}
+}
+
+// ForgotPassword mails a password reset link. It answers the same whether or not the
+// email is registered so it can't be used to find accounts.
+func ForgotPassword(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Email string `json:"email"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.Email == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "please provide an email"})
+		}
+
+		response := fiber.Map{"message": "if the email is registered, a reset link has been sent"}
+
+		var user models.User
+		if err := db.Where("email = ?", input.Email).First(&user).Error; err != nil {
+			return c.JSON(response)
+		}
+
+		token, err := issueUserToken(db, user.ID, models.TokenPasswordReset, passwordResetTTL)
+		if err != nil {
+			log.Printf("Failed to issue password reset token for user ID %d: %v", user.ID, err)
+			return c.JSON(response)
+		}
+
+		link := appURL() + "/reset-password?token=" + url.QueryEscape(token)
+		if err := queueEmail(db, user, models.TokenPasswordReset, mailer.TemplateData{Link: link}); err != nil {
+			log.Printf("Failed to queue password reset email for user ID %d: %v", user.ID, err)
+		}
+
+		return c.JSON(response)
+	}
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out everywhere
+func ResetPassword(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Token    string `json:"token"`
+			Password string `json:"password"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.Token == "" || input.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "please provide token and password"})
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), 12)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not hash password"})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			userID, err := consumeUserToken(tx, input.Token, models.TokenPasswordReset)
+			if err != nil {
+				return err
+			}
+
+			// Following the emailed link also proves the address belongs to the user
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).
+				Updates(map[string]interface{}{"password": string(hashedPassword), "verified": true}).Error; err != nil {
+				return err
+			}
+			return revokeUserSessions(tx, userID)
+		})
+		if errors.Is(err, errInvalidUserToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not reset password"})
+		}
+
+		return c.JSON(fiber.Map{"message": "password reset successfully"})
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSignToken(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	signature := signToken("token")
+	if signToken("token") != signature {
+		t.Fatal("the same token was signed differently")
+	}
+	if signToken("token2") == signature {
+		t.Fatal("another token has the same signature")
+	}
+
+	t.Setenv("SECRET", "other-secret")
+	if signToken("token") == signature {
+		t.Fatal("the signature doesn't depend on the secret")
+	}
+}
+
+func TestConsumeUserToken(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+
+	issue := func(purpose string, ttl time.Duration) string {
+		t.Helper()
+		token, err := issueUserToken(db, user.ID, purpose, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	token := issue(models.TokenPasswordReset, time.Hour)
+	if userID, err := consumeUserToken(db, token, models.TokenPasswordReset); err != nil || userID != user.ID {
+		t.Fatalf("consumeUserToken = %d, %v", userID, err)
+	}
+
+	tampered := issue(models.TokenPasswordReset, time.Hour)
+	replaced := issue(models.TokenVerifyEmail, time.Hour)
+	issue(models.TokenVerifyEmail, time.Hour)
+	tests := []struct {
+		name    string
+		token   string
+		purpose string
+	}{
+		{"used", token, models.TokenPasswordReset},
+		{"expired", issue(models.TokenVerifyEmail, -time.Minute), models.TokenVerifyEmail},
+		{"tampered", tampered + "x", models.TokenPasswordReset},
+		{"other purpose", tampered, models.TokenVerifyEmail},
+		{"replaced by a newer token", replaced, models.TokenVerifyEmail},
+		{"never issued", "not-a-token", models.TokenVerifyEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := consumeUserToken(db, tt.token, tt.purpose); !errors.Is(err, errInvalidUserToken) {
+				t.Fatalf("consumeUserToken = %v, want errInvalidUserToken", err)
+			}
+		})
+	}
+
+	// Only the signature is stored, the token itself can't be found in the table
+	var stored int64
+	db.Model(&models.UserToken{}).Where("token_hash = ?", tampered).Count(&stored)
+	if stored != 0 {
+		t.Fatal("the token is stored unsigned")
+	}
+
+	t.Setenv("SECRET", "other-secret")
+	if _, err := consumeUserToken(db, tampered, models.TokenPasswordReset); !errors.Is(err, errInvalidUserToken) {
+		t.Fatalf("a token signed with another secret returned %v", err)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	db.Model(&user).Update("verified", false)
+	token, err := issueUserToken(db, user.ID, models.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/verify-email", VerifyEmail(db))
+	path := "/verify-email?token=" + url.QueryEscape(token)
+
+	if status, body := send(t, app, http.MethodGet, path, ""); status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	db.First(&user, user.ID)
+	if !user.Verified {
+		t.Fatal("the email wasn't verified")
+	}
+	if status, _ := send(t, app, http.MethodGet, path, ""); status != http.StatusBadRequest {
+		t.Fatalf("verifying twice returned %d, want 400", status)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	createTestSession(t, db, user)
+	createTestSession(t, db, user)
+	token, err := issueUserToken(db, user.ID, models.TokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/password/reset", ResetPassword(db))
+	body := `{"token":"` + token + `","password":"new password"}`
+
+	if status, body := send(t, app, http.MethodPost, "/password/reset", body); status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	db.First(&user, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new password")) != nil {
+		t.Fatal("the new password wasn't stored")
+	}
+	if n := activeSessions(t, db, user); n != 0 {
+		t.Fatalf("%d sessions still active after the reset", n)
+	}
+
+	// A reset link works once
+	if status, _ := send(t, app, http.MethodPost, "/password/reset", `{"token":"`+token+`","password":"another"}`); status != http.StatusBadRequest {
+		t.Fatalf("reusing the token returned %d, want 400", status)
+	}
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"time"
 	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
@@ -13,6 +15,7 @@ import (
 type TranslatorWithRating struct {
 	models.User
 	AverageRating float64 `json:"average_rating"`
+	Workload      int64   `json:"workload"` // Documents assigned to the translator that aren't finished yet
 }
 
 func RegisterAdmin(db *gorm.DB) fiber.Handler {
@@ -47,6 +50,7 @@ func RegisterAdmin(db *gorm.DB) fiber.Handler {
 			Email:    input.Email,
 			Password: string(hashedPassword),
 			Role:     "admin",
+			Verified: true, // Created by another admin, nobody to confirm the address
 		}
 
 		if err := db.Create(&user).Error; err != nil {
@@ -82,7 +86,7 @@ func GetDocumentDetails(db *gorm.DB) fiber.Handler {
 	}
 }
 
-func DownloadUserDocument(db *gorm.DB) fiber.Handler {
+func DownloadUserDocument(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		documentID := c.Params("id")
 
@@ -92,16 +96,12 @@ func DownloadUserDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		if document.FileContent == nil {
+		if document.FileKey == "" {
 			log.Printf("Document file not found for document ID: %v", documentID)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document file not found"})
 		}
 
-		// Set the appropriate headers
-		c.Set("Content-Disposition", "attachment; filename="+document.FileName)
-		c.Set("Content-Type", "application/octet-stream")
-
-		return c.Send(document.FileContent)
+		return sendBlob(c, store, document.FileKey, document.FileName)
 	}
 }
 
@@ -115,13 +115,12 @@ func ApproveDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		document.ApprovalStatus = "Approved"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		if err := changeDocumentState(db, &document, models.StateApproved, actorFrom(c), models.EventApproved, ""); err != nil {
+			return stateChangeError(c, err)
 		}
 
 		message := "Your document has been approved."
-		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
+		if err := CreateTypedNotification(models.NotificationDocumentApproved, document.UserID, document.ID, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
 
@@ -139,12 +138,16 @@ func RejectDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		document.ApprovalStatus = "Rejected"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		code, comment, err := parseReason(c, models.EventRejected)
+		if err != nil {
+			return reasonError(c, models.EventRejected, err)
 		}
 
-		message := "Your document has been rejected."
+		if err := changeDocumentStateWithReason(db, &document, models.StateRejected, actorFrom(c), models.EventRejected, code, comment); err != nil {
+			return stateChangeError(c, err)
+		}
+
+		message := "Your document has been rejected." + reasonText(models.EventRejected, code, comment)
 		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
@@ -168,20 +171,36 @@ func AssignDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		if document.ApprovalStatus != "Approved" {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Document must be approved before assigning a translator"})
+		var translator models.User
+		if err := db.Where("id = ? AND role = ?", request.TranslatorID, models.RoleTranslator).First(&translator).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Translator not found"})
+		}
+		if translator.VettingStatus != models.VettingApproved {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Translator has not been vetted yet"})
+		}
+		previous, err := previouslyOfferedTranslators(db, document.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check assignment history"})
+		}
+		for _, id := range previous {
+			if id == translator.ID {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Translator already declined or let this document expire"})
+			}
+		}
+		if err := checkTranslatorAvailable(db, &translator); err != nil {
+			if errors.Is(err, errTranslatorAtCapacity) || errors.Is(err, errTranslatorAway) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check translator availability"})
 		}
 
-		document.TranslatorID = request.TranslatorID
-		document.TranslatorApprovalStatus = "Pending"
-		document.AssignmentTime = time.Now() // Set the assignment time
-
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		// Only paid documents can be assigned, see models.documentTransitions
+		if err := assignTranslator(db, &document, request.TranslatorID, actorFrom(c), ""); err != nil {
+			return stateChangeError(c, err)
 		}
 
 		message := "A document has been assigned to you."
-		if err := CreateNotification(document.TranslatorID, document.ID, message, db); err != nil {
+		if err := CreateTypedNotification(models.NotificationDocumentAssigned, document.TranslatorID, document.ID, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
 
@@ -189,6 +208,32 @@ func AssignDocument(db *gorm.DB) fiber.Handler {
 	}
 }
 
+// GetUnassignedDocuments lists paid documents waiting for a translator, longest waiting first
+func GetUnassignedDocuments(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var documents []models.Document
+		if err := db.Where("state = ?", models.StatePaid).Order("updated_at asc").Find(&documents).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch documents"})
+		}
+
+		return c.JSON(documents)
+	}
+}
+
+// GetAssignmentAttempts returns every translator a document was offered to and how each offer ended
+func GetAssignmentAttempts(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		documentID := c.Params("id")
+
+		var attempts []models.AssignmentAttempt
+		if err := db.Where("document_id = ?", documentID).Order("created_at asc").Find(&attempts).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch assignment history"})
+		}
+
+		return c.JSON(attempts)
+	}
+}
+
 func GetTranslators(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var translators []models.User
@@ -200,7 +245,7 @@ func GetTranslators(db *gorm.DB) fiber.Handler {
 	}
 }
 
-func DownloadTranslatedFile(db *gorm.DB) fiber.Handler {
+func DownloadTranslatedFile(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		documentID := c.Params("id")
 
@@ -209,15 +254,11 @@ func DownloadTranslatedFile(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		if document.FileContent == nil {
+		if document.TranslatedFileKey == "" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translated document not found"})
 		}
 
-		// Set the appropriate headers
-		c.Set("Content-Disposition", "attachment; filename="+document.TranslatedFileName)
-		c.Set("Content-Type", "application/octet-stream")
-
-		return c.Send(document.TranslatedFileContent)
+		return sendBlob(c, store, document.TranslatedFileKey, document.TranslatedFileName)
 	}
 }
 
@@ -230,19 +271,29 @@ func ApproveTranslatedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		document.TranslatedApprovalStatus = "Approved"
-		document.Status = "Finished"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		deliveredAt := time.Now()
+		document.DeliveredAt = &deliveredAt
+		// The translator is credited with the delivery, never one without the other
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := changeDocumentState(tx, &document, models.StateDelivered, actorFrom(c), models.EventTranslationApproved, ""); err != nil {
+				return err
+			}
+			return creditTranslation(tx, &document)
+		})
+		if err != nil {
+			return stateChangeError(c, err)
+		}
+		if err := reviewTranslationVersion(db, &document, models.VersionApproved, actorFrom(c)); err != nil {
+			log.Printf("Failed to record delivered version of document ID %d: %v", document.ID, err)
 		}
 
 		message := "Your document has been translated."
-		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
+		if err := CreateTypedNotification(models.NotificationDocumentTranslated, document.UserID, document.ID, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Can't create notification"})
 		}
 
 		var count int64
-		db.Model(&models.Document{}).Where("translator_id = ? AND status = ?", document.TranslatorID, "Translating").Count(&count)
+		db.Model(&models.Document{}).Where("translator_id = ? AND state IN ?", document.TranslatorID, []models.DocumentState{models.StateTranslating, models.StateInReview}).Count(&count)
 
 		if count == 0 {
 			var translator models.User
@@ -274,12 +325,19 @@ func RejectTranslatedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		document.TranslatedApprovalStatus = "Rejected"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		code, comment, err := parseReason(c, models.EventTranslationRejected)
+		if err != nil {
+			return reasonError(c, models.EventTranslationRejected, err)
 		}
 
-		message := "Your translation has been rejected by Admin."
+		if err := changeDocumentStateWithReason(db, &document, models.StateTranslating, actorFrom(c), models.EventTranslationRejected, code, comment); err != nil {
+			return stateChangeError(c, err)
+		}
+		if err := reviewTranslationVersion(db, &document, models.VersionRejected, actorFrom(c)); err != nil {
+			log.Printf("Failed to record rejected version of document ID %d: %v", document.ID, err)
+		}
+
+		message := "Your translation has been rejected by Admin." + reasonText(models.EventTranslationRejected, code, comment)
 		if err := CreateNotification(document.TranslatorID, document.ID, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
@@ -288,7 +346,7 @@ func RejectTranslatedDocument(db *gorm.DB) fiber.Handler {
 	}
 }
 
-func DownloadPaymentReceipt(db *gorm.DB) fiber.Handler {
+func DownloadPaymentReceipt(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		documentID := c.Params("id")
 
@@ -297,16 +355,11 @@ func DownloadPaymentReceipt(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		if document.PaymentReceiptContent == nil {
+		if document.PaymentReceiptKey == "" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Payment receipt not found"})
 		}
 
-		// Set the appropriate headers
-		c.Set("Content-Disposition", "attachment; filename="+document.PaymentReceiptFileName)
-		c.Set("Content-Type", "application/octet-stream")
-
-		// Send the file content directly from the database
-		return c.Send(document.PaymentReceiptContent)
+		return sendBlob(c, store, document.PaymentReceiptKey, document.PaymentReceiptFileName)
 	}
 }
 
@@ -319,15 +372,12 @@ func ApprovePayment(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
 		}
 
-		document.PaymentConfirmed = true
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		schedulePayment(db, &document)
+		if err := changeDocumentState(db, &document, models.StatePaid, actorFrom(c), models.EventPaymentApproved, ""); err != nil {
+			return stateChangeError(c, err)
 		}
 
-		message := "Your payment has been approved by Admin."
-		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
-		}
+		paymentConfirmed(db, &document, "Your payment has been approved by Admin.")
 
 		return c.JSON(fiber.Map{"message": "Payment approved successfully"})
 	}
@@ -356,19 +406,17 @@ func GetTranslatorsByLanguage(db *gorm.DB) fiber.Handler {
 			})
 		}
 
-		query := `
-			SELECT users.*, COALESCE(AVG(ratings.rating), 0) as average_rating
-			FROM users
-			LEFT JOIN ratings ON users.id = ratings.translator_id
-			WHERE users.role = ?
-			AND ARRAY[?] <@ users.proficient_languages 
-			AND ARRAY[?] <@ users.proficient_languages
-			AND ARRAY[?] <@ users.categories
-			GROUP BY users.id
-			ORDER BY average_rating DESC, users.status ASC`
+		// When matching for a specific document, leave out translators who already turned it down
+		var exclude []uint
+		if documentID := c.QueryInt("document_id"); documentID > 0 {
+			var err error
+			if exclude, err = previouslyOfferedTranslators(db, uint(documentID)); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch assignment history"})
+			}
+		}
 
-		var translators []TranslatorWithRating
-		if err := db.Raw(query, "translator", sourceLanguage, targetLanguage, category).Scan(&translators).Error; err != nil {
+		translators, err := findTranslators(db, sourceLanguage, targetLanguage, category, exclude)
+		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch translators"})
 		}
 
@@ -388,7 +436,7 @@ func DeleteTranslator(db *gorm.DB) fiber.Handler {
 
 		// Check if translator has any ongoing translations
 		var ongoingDocuments int64
-		if err := db.Model(&models.Document{}).Where("translator_id = ? AND status = ?", translatorID, "Translating").Count(&ongoingDocuments).Error; err != nil {
+		if err := db.Model(&models.Document{}).Where("translator_id = ? AND state IN ?", translatorID, activeJobStates).Count(&ongoingDocuments).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check ongoing translations"})
 		}
 
@@ -401,6 +449,11 @@ func DeleteTranslator(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete translator"})
 		}
 
+		// Authenticated rejects deleted users anyway, this also stops their refresh tokens
+		if err := revokeUserSessions(db, translator.ID); err != nil {
+			log.Printf("Failed to revoke sessions of translator ID %d: %v", translator.ID, err)
+		}
+
 		return c.JSON(fiber.Map{"message": "Translator deleted successfully"})
 	}
 }
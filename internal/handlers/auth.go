@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"os"
 	"time"
 	"translation-app-backend/internal/models"
@@ -59,11 +60,13 @@ func Register(db *gorm.DB) fiber.Handler {
 			user.ProficientLanguages = input.ProficientLanguages
 			user.Categories = input.Categories
 			user.Status = "Available"
+			// Applications are vetted by an admin before the translator gets any work
+			user.VettingStatus = models.VettingPendingReview
+		}
 
-			// Validate the translator's categories
-			if err := user.Validate(); err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-			}
+		// Validate the email, role and the translator's languages and categories
+		if err := user.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
 		// Save the user to the database
@@ -72,6 +75,17 @@ func Register(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
 		}
 
+		// The account works right away, uploads wait until the email is confirmed
+		if err := sendVerificationEmail(db, user); err != nil {
+			log.Printf("Failed to send verification email to user ID %d: %v", user.ID, err)
+		}
+
+		if user.Role == models.RoleTranslator {
+			if err := notifyAllAdmins("A new translator application is waiting for review.", db); err != nil {
+				log.Printf("Failed to notify admins about translator ID %d: %v", user.ID, err)
+			}
+		}
+
 		return c.JSON(user)
 	}
 }
@@ -100,23 +114,26 @@ func Login(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "incorrect password"})
 		}
 
-		// Generate JWT token
-		token, err := generateJWT(user)
+		// Start a session and generate its tokens
+		tokens, err := startSession(db, c, user)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not generate token"})
 		}
 
-		return c.JSON(fiber.Map{"message": "login successful", "token": token, "userRole": user.Role})
+		tokens["message"] = "login successful"
+		return c.JSON(tokens)
 	}
 }
 
-func generateJWT(user models.User) (string, error) {
+// generateJWT issues a short-lived access token bound to a session, clients renew it with their refresh token
+func generateJWT(user models.User, sessionID uint) (string, error) {
 	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id":  user.ID,
+		"sid":      sessionID,
 		"email":    user.Email,
 		"userRole": user.Role,
 		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		"exp":      time.Now().Add(accessTokenTTL).Unix(),
 	})
 	token, err := claims.SignedString([]byte(os.Getenv("SECRET"))) // Use a secret from env variable in production
 	return token, err
@@ -144,7 +161,7 @@ func UpdateTranslatorCategories(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only translators can update categories"})
 		}
 
-		user.Categories = input.Categories
+		backToReview := applyTranslatorSkills(&user, nil, input.Categories)
 
 		// Validate the updated categories
 		if err := user.Validate(); err != nil {
@@ -154,6 +171,9 @@ func UpdateTranslatorCategories(db *gorm.DB) fiber.Handler {
 		if err := db.Save(&user).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update categories"})
 		}
+		if backToReview {
+			requestReview(db, &user)
+		}
 
 		return c.JSON(fiber.Map{
 			"message":    "Categories updated successfully",
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetAvailability returns the capacity, working hours and upcoming time off of the translator
+func GetAvailability(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		var hours []models.WorkingHours
+		if err := db.Where("user_id = ?", user.ID).Order("weekday asc, start_time asc").Find(&hours).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch working hours"})
+		}
+
+		var timeOff []models.TimeOff
+		if err := db.Where("user_id = ? AND ends_at > ?", user.ID, time.Now()).Order("starts_at asc").Find(&timeOff).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch time off"})
+		}
+
+		var workload int64
+		if err := db.Model(&models.Document{}).Where("translator_id = ? AND state IN ?", user.ID, activeJobStates).Count(&workload).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count active jobs"})
+		}
+
+		return c.JSON(fiber.Map{
+			"available":           user.Available,
+			"max_concurrent_jobs": user.MaxConcurrentJobs,
+			"words_per_day":       user.WordsPerDay,
+			"active_jobs":         workload,
+			"time_zone":           user.TimeZone,
+			"working_hours":       hours,
+			"time_off":            timeOff,
+		})
+	}
+}
+
+// UpdateAvailability sets the capacity and time zone of the translator. When working_hours
+// is sent it replaces the whole weekly schedule, an empty list means any time.
+func UpdateAvailability(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var input struct {
+			MaxConcurrentJobs *int    `json:"max_concurrent_jobs"`
+			WordsPerDay       *int    `json:"words_per_day"`
+			TimeZone          *string `json:"time_zone"`
+			WorkingHours      *[]struct {
+				Weekday   int    `json:"weekday"`
+				StartTime string `json:"start_time"`
+				EndTime   string `json:"end_time"`
+			} `json:"working_hours"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		updates := map[string]interface{}{}
+		if input.MaxConcurrentJobs != nil {
+			if *input.MaxConcurrentJobs < 1 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "max_concurrent_jobs must be at least 1"})
+			}
+			updates["max_concurrent_jobs"] = *input.MaxConcurrentJobs
+		}
+		if input.WordsPerDay != nil {
+			if *input.WordsPerDay < 1 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "words_per_day must be at least 1"})
+			}
+			updates["words_per_day"] = *input.WordsPerDay
+		}
+		if input.TimeZone != nil {
+			if _, err := time.LoadLocation(*input.TimeZone); err != nil || *input.TimeZone == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid time zone"})
+			}
+			updates["time_zone"] = *input.TimeZone
+		}
+
+		var hours []models.WorkingHours
+		if input.WorkingHours != nil {
+			for _, slot := range *input.WorkingHours {
+				hour := models.WorkingHours{
+					UserID:    user.ID,
+					Weekday:   slot.Weekday,
+					StartTime: slot.StartTime,
+					EndTime:   slot.EndTime,
+				}
+				if err := hour.Validate(); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+				}
+				hours = append(hours, hour)
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if len(updates) > 0 {
+				if err := tx.Model(&user).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			if input.WorkingHours == nil {
+				return nil
+			}
+
+			// Slots are replaced, not kept as history
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.WorkingHours{}).Error; err != nil {
+				return err
+			}
+			if len(hours) == 0 {
+				return nil
+			}
+			return tx.Create(&hours).Error
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update availability"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Availability updated successfully"})
+	}
+}
+
+// CreateTimeOff records a period the translator is away
+func CreateTimeOff(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var input struct {
+			StartsAt time.Time `json:"starts_at"`
+			EndsAt   time.Time `json:"ends_at"`
+			Reason   string    `json:"reason"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request, dates must be RFC 3339"})
+		}
+
+		timeOff := models.TimeOff{
+			UserID:   uint(userID),
+			StartsAt: input.StartsAt,
+			EndsAt:   input.EndsAt,
+			Reason:   input.Reason,
+		}
+		if err := timeOff.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Create(&timeOff).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save time off"})
+		}
+
+		return c.JSON(timeOff)
+	}
+}
+
+// DeleteTimeOff removes a period of time off of the translator
+func DeleteTimeOff(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		result := db.Where("id = ? AND user_id = ?", c.Params("id"), uint(userID)).Delete(&models.TimeOff{})
+		if result.Error != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete time off"})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Time off not found"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Time off deleted successfully"})
+	}
+}
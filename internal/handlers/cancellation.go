@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cancellationPolicy returns the percent of the price refunded and paid to the translator
+// when a document is cancelled after assignment
+func cancellationPolicy(db *gorm.DB) (int, int) {
+	var settings models.Settings
+	if err := db.First(&settings).Error; err != nil {
+		return models.DefaultCancellationRefundPercent, models.DefaultCancellationCompensationPercent
+	}
+	return settings.CancellationRefundPercent, settings.CancellationCompensationPercent
+}
+
+// formatMoney writes an amount the way it is shown in notifications
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// CancelDocument cancels a document for its owner, the refund and the translator's
+// compensation depend on how far the document got, see models.CancellationTerms
+func CancelDocument(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+		document := c.Locals("document").(*models.Document)
+
+		if document.UserID != by.ID && by.Role != models.RoleAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can cancel it"})
+		}
+
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		// The reason is optional
+		_ = c.BodyParser(&input)
+
+		refundPercent, compensationPercent := cancellationPolicy(db)
+		cancellation, err := models.CancellationTerms(document, refundPercent, compensationPercent)
+		if err != nil {
+			return stateChangeError(c, err)
+		}
+		cancellation.RequestedByID = by.ID
+		cancellation.Reason = input.Reason
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := changeDocumentState(tx, document, models.StateCancelled, by, models.EventCancelled, input.Reason); err != nil {
+				return err
+			}
+			if err := tx.Create(&cancellation).Error; err != nil {
+				return err
+			}
+			return creditTranslator(tx, cancellation.TranslatorID, document, models.LedgerCancellation, cancellation.Compensation, "Compensation for cancelled "+document.Title)
+		})
+		if err != nil {
+			return stateChangeError(c, err)
+		}
+
+		if cancellation.FromState == models.StateAssigned {
+			if err := closeAssignmentAttempt(db, document.ID, cancellation.TranslatorID, models.AssignmentCancelled); err != nil {
+				log.Printf("Failed to close assignment attempt for document ID %d: %v", document.ID, err)
+			}
+		}
+
+		message := "Your document has been cancelled."
+		if cancellation.RefundDue > 0 {
+			message += " A refund of " + formatMoney(cancellation.RefundDue) + " will be sent to you."
+		}
+		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
+			log.Printf("Failed to notify user ID %d: %v", document.UserID, err)
+		}
+
+		if cancellation.TranslatorID != 0 {
+			message := "A document assigned to you has been cancelled, please stop working on it."
+			if cancellation.Compensation > 0 {
+				message += " You will be paid " + formatMoney(cancellation.Compensation) + " for the work already done."
+			}
+			if err := CreateNotification(cancellation.TranslatorID, document.ID, message, db); err != nil {
+				log.Printf("Failed to notify translator ID %d: %v", cancellation.TranslatorID, err)
+			}
+		}
+
+		adminMessage := "A document has been cancelled."
+		if cancellation.RefundDue > 0 {
+			adminMessage += " A refund of " + formatMoney(cancellation.RefundDue) + " is due."
+		}
+		if err := notifyAdmins(document, adminMessage, db); err != nil {
+			log.Printf("Failed to notify admins about document ID %d: %v", document.ID, err)
+		}
+
+		return c.JSON(cancellation)
+	}
+}
+
+// GetCancellation returns the cancellation of a document and the refunds made for it
+func GetCancellation(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if !canSeeInvoices(document, actorFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can see its cancellation"})
+		}
+
+		var cancellation models.Cancellation
+		if err := db.Where("document_id = ?", document.ID).First(&cancellation).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document was not cancelled"})
+		}
+
+		var refunds []models.Refund
+		if err := db.Where("document_id = ?", document.ID).Order("created_at asc").Find(&refunds).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch refunds"})
+		}
+
+		return c.JSON(fiber.Map{
+			"cancellation": cancellation,
+			"refunds":      refunds,
+		})
+	}
+}
+
+// errRefundTooLarge is returned when refunds would add up to more than the customer paid
+var errRefundTooLarge = errors.New("refunds can't exceed the amount paid")
+
+// RecordRefund stores a refund an admin made for a cancelled document, together with the
+// proof of the transfer. Refunds can be split but never exceed what the customer paid.
+func RecordRefund(db *gorm.DB, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+
+		var document models.Document
+		if err := db.Where("id = ?", c.Params("id")).First(&document).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		}
+
+		var cancellation models.Cancellation
+		if err := db.Where("document_id = ?", document.ID).First(&cancellation).Error; err != nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only cancelled documents can be refunded"})
+		}
+
+		amount, err := strconv.ParseFloat(c.FormValue("amount"), 64)
+		if err != nil || amount <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "amount must be a positive number"})
+		}
+		amount = models.RoundMoney(amount)
+
+		proof, err := c.FormFile("proof")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A proof of the transfer is required"})
+		}
+
+		key, err := saveUpload(c, store, "refunds", proof)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to store file: " + err.Error()})
+		}
+
+		refund := models.Refund{
+			DocumentID:     document.ID,
+			CancellationID: cancellation.ID,
+			Amount:         amount,
+			Method:         c.FormValue("method"),
+			Reference:      c.FormValue("reference"),
+			ProofKey:       key,
+			ProofFileName:  proof.Filename,
+			RecordedByID:   by.ID,
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Lock the cancellation so two admins can't refund the same money
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cancellation, cancellation.ID).Error; err != nil {
+				return err
+			}
+
+			var refunded float64
+			if err := tx.Model(&models.Refund{}).Where("cancellation_id = ?", cancellation.ID).Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error; err != nil {
+				return err
+			}
+			if models.RoundMoney(refunded+amount) > cancellation.AmountPaid {
+				return errRefundTooLarge
+			}
+
+			return tx.Create(&refund).Error
+		})
+		if errors.Is(err, errRefundTooLarge) {
+			deleteBlob(c, store, key)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			deleteBlob(c, store, key)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save refund"})
+		}
+
+		if err := recordDocumentEvent(db, &document, by, models.EventRefunded, "Refunded "+formatMoney(amount)); err != nil {
+			log.Printf("Failed to record event for document ID %d: %v", document.ID, err)
+		}
+
+		message := "A refund of " + formatMoney(amount) + " has been sent to you."
+		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
+			log.Printf("Failed to notify user ID %d: %v", document.UserID, err)
+		}
+
+		return c.JSON(refund)
+	}
+}
+
+// DownloadRefundProof sends the proof of a refund to the owner of the document or an admin
+func DownloadRefundProof(db *gorm.DB, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if !canSeeInvoices(document, actorFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can download its refund proofs"})
+		}
+
+		var refund models.Refund
+		if err := db.Where("id = ? AND document_id = ?", c.Params("refundId"), document.ID).First(&refund).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Refund not found"})
+		}
+
+		return sendBlob(c, store, refund.ProofKey, refund.ProofFileName)
+	}
+}
+
+// UpdateCancellationPolicy sets the percent of the price refunded and paid to the
+// translator when a document is cancelled after assignment
+func UpdateCancellationPolicy(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			RefundPercent       int `json:"refund_percent"`
+			CompensationPercent int `json:"compensation_percent"`
+		}
+
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+		if input.RefundPercent < 0 || input.RefundPercent > 100 || input.CompensationPercent < 0 || input.CompensationPercent > 100 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Percentages must be between 0 and 100"})
+		}
+
+		var settings models.Settings
+		if err := db.FirstOrCreate(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load settings"})
+		}
+
+		settings.CancellationRefundPercent = input.RefundPercent
+		settings.CancellationCompensationPercent = input.CompensationPercent
+		if err := db.Save(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update settings"})
+		}
+
+		return c.JSON(fiber.Map{
+			"message":              "Cancellation policy updated successfully",
+			"refund_percent":       settings.CancellationRefundPercent,
+			"compensation_percent": settings.CancellationCompensationPercent,
+		})
+	}
+}
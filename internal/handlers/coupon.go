@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errQuoteAccepted is returned when a coupon is changed after the quote was accepted
+var errQuoteAccepted = errors.New("coupons can only be changed before the quote is accepted")
+
+// couponStates are the states a document's quote can still be discounted in. The invoice
+// is issued when the quote is accepted, so the discount is settled by then.
+var couponStates = []models.DocumentState{models.StateSubmitted, models.StateApproved}
+
+func couponOpen(document *models.Document) bool {
+	for _, state := range couponStates {
+		if document.State == state {
+			return true
+		}
+	}
+	return false
+}
+
+// countRedemptions counts the redemptions of a coupon, only those of userID when it isn't 0
+func countRedemptions(tx *gorm.DB, couponID uint, userID uint) (int64, error) {
+	query := tx.Model(&models.CouponRedemption{}).
+		Joins("JOIN documents ON documents.id = coupon_redemptions.document_id").
+		Where("coupon_redemptions.coupon_id = ? AND documents.state <> ?", couponID, models.StateCancelled)
+	if userID != 0 {
+		query = query.Where("coupon_redemptions.user_id = ?", userID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// checkCouponLimits refuses a coupon that reached its total or per customer limit
+func checkCouponLimits(tx *gorm.DB, coupon *models.Coupon, userID uint) error {
+	if coupon.MaxUses > 0 {
+		count, err := countRedemptions(tx, coupon.ID, 0)
+		if err != nil {
+			return err
+		}
+		if count >= int64(coupon.MaxUses) {
+			return models.ErrCouponUsedUp
+		}
+	}
+	if coupon.MaxUsesPerUser > 0 {
+		count, err := countRedemptions(tx, coupon.ID, userID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(coupon.MaxUsesPerUser) {
+			return models.ErrCouponAlreadyUsed
+		}
+	}
+	return nil
+}
+
+// saveDiscountedQuote stores the quote of a document as long as it hasn't been accepted meanwhile
+func saveDiscountedQuote(tx *gorm.DB, document *models.Document) error {
+	result := tx.Model(&models.Document{}).
+		Where("id = ? AND state IN ?", document.ID, couponStates).
+		Updates(map[string]interface{}{
+			"quote_amount":      document.Quote.Amount,
+			"quote_discount":    document.Quote.Discount,
+			"quote_coupon_code": document.Quote.CouponCode,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errQuoteAccepted
+	}
+	return nil
+}
+
+// reapplyCoupon recomputes the discount after the document was quoted again. A coupon
+// that no longer fits the new price is dropped.
+func reapplyCoupon(tx *gorm.DB, document *models.Document) error {
+	var redemption models.CouponRedemption
+	err := tx.Where("document_id = ?", document.ID).First(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var coupon models.Coupon
+	if err := tx.Unscoped().First(&coupon, redemption.CouponID).Error; err != nil {
+		return err
+	}
+
+	discount := document.ApplyCoupon(&coupon)
+	if discount == 0 {
+		document.RemoveCoupon()
+		return tx.Unscoped().Delete(&redemption).Error
+	}
+	return tx.Model(&redemption).Update("discount", discount).Error
+}
+
+// couponError turns a failure to apply a coupon into a response
+func couponError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Unknown coupon code"})
+	case errors.Is(err, errQuoteAccepted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, models.ErrCouponNotValid), errors.Is(err, models.ErrCouponNotApplicable),
+		errors.Is(err, models.ErrCouponUsedUp), errors.Is(err, models.ErrCouponAlreadyUsed), errors.Is(err, models.ErrCouponTooLarge):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to apply coupon"})
+	}
+}
+
+// ApplyCoupon takes the discount of a coupon code off the quote of a document before
+// the customer accepts it
+func ApplyCoupon(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+		document := c.Locals("document").(*models.Document)
+
+		var input struct {
+			Code string `json:"code"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		code := models.NormalizeCouponCode(input.Code)
+		if code == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A coupon code is required"})
+		}
+
+		if document.UserID != by.ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can apply a coupon"})
+		}
+		if document.Quote.QuotedAt == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Document has not been priced yet"})
+		}
+		if !couponOpen(document) {
+			return couponError(c, errQuoteAccepted)
+		}
+		if document.Quote.CouponCode != "" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A coupon is already applied, remove it first"})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Locking the coupon keeps concurrent redemptions from going over its limits
+			var coupon models.Coupon
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&coupon).Error; err != nil {
+				return err
+			}
+			if err := coupon.AppliesTo(document, time.Now()); err != nil {
+				return err
+			}
+			if err := checkCouponLimits(tx, &coupon, document.UserID); err != nil {
+				return err
+			}
+
+			discount := document.ApplyCoupon(&coupon)
+			if err := tx.Create(&models.CouponRedemption{
+				CouponID:   coupon.ID,
+				UserID:     document.UserID,
+				DocumentID: document.ID,
+				Discount:   discount,
+			}).Error; err != nil {
+				return err
+			}
+			if err := saveDiscountedQuote(tx, document); err != nil {
+				return err
+			}
+			return recordDocumentEvent(tx, document, by, models.EventCouponApplied, coupon.Code+" took "+formatMoney(discount)+" off")
+		})
+		if err != nil {
+			return couponError(c, err)
+		}
+
+		return c.JSON(fiber.Map{"message": "Coupon applied", "quote": document.Quote})
+	}
+}
+
+// RemoveCoupon takes the coupon off a document whose quote hasn't been accepted yet
+func RemoveCoupon(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+		document := c.Locals("document").(*models.Document)
+
+		if document.UserID != by.ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can remove its coupon"})
+		}
+		if document.Quote.CouponCode == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No coupon is applied"})
+		}
+		if !couponOpen(document) {
+			return couponError(c, errQuoteAccepted)
+		}
+
+		code := document.Quote.CouponCode
+		document.RemoveCoupon()
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Deleted for good so the document can take a coupon again
+			if err := tx.Unscoped().Where("document_id = ?", document.ID).Delete(&models.CouponRedemption{}).Error; err != nil {
+				return err
+			}
+			if err := saveDiscountedQuote(tx, document); err != nil {
+				return err
+			}
+			return recordDocumentEvent(tx, document, by, models.EventCouponRemoved, code)
+		})
+		if err != nil {
+			return couponError(c, err)
+		}
+
+		return c.JSON(fiber.Map{"message": "Coupon removed", "quote": document.Quote})
+	}
+}
+
+type couponInput struct {
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	Kind           string     `json:"kind"`
+	Value          float64    `json:"value"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	Category       string     `json:"category"`
+	SourceLanguage string     `json:"source_language"`
+	TargetLanguage string     `json:"target_language"`
+	Active         *bool      `json:"active"`
+}
+
+func (input couponInput) apply(coupon *models.Coupon) {
+	coupon.Code = models.NormalizeCouponCode(input.Code)
+	coupon.Description = input.Description
+	coupon.Kind = input.Kind
+	coupon.Value = input.Value
+	coupon.ValidFrom = input.ValidFrom
+	coupon.ValidUntil = input.ValidUntil
+	coupon.MaxUses = input.MaxUses
+	coupon.MaxUsesPerUser = input.MaxUsesPerUser
+	coupon.Category = input.Category
+	coupon.SourceLanguage = input.SourceLanguage
+	coupon.TargetLanguage = input.TargetLanguage
+	if input.Active != nil {
+		coupon.Active = *input.Active
+	}
+}
+
+// couponCodeTaken reports whether another coupon already uses the code
+func couponCodeTaken(db *gorm.DB, coupon *models.Coupon) (bool, error) {
+	var count int64
+	err := db.Unscoped().Model(&models.Coupon{}).Where("code = ? AND id <> ?", coupon.Code, coupon.ID).Count(&count).Error
+	return count > 0, err
+}
+
+func GetCoupons(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var coupons []models.Coupon
+		if err := db.Order("id asc").Find(&coupons).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch coupons"})
+		}
+
+		return c.JSON(coupons)
+	}
+}
+
+func CreateCoupon(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input couponInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		coupon := models.Coupon{Active: true}
+		input.apply(&coupon)
+		if err := coupon.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		taken, err := couponCodeTaken(db, &coupon)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create coupon"})
+		}
+		if taken {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A coupon with this code already exists"})
+		}
+
+		if err := db.Create(&coupon).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create coupon"})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(coupon)
+	}
+}
+
+// UpdateCoupon changes a coupon, discounts already applied to documents stay as they are
+func UpdateCoupon(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var coupon models.Coupon
+		if err := db.First(&coupon, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coupon not found"})
+		}
+
+		var input couponInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		input.apply(&coupon)
+		if err := coupon.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		taken, err := couponCodeTaken(db, &coupon)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update coupon"})
+		}
+		if taken {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A coupon with this code already exists"})
+		}
+
+		if err := db.Save(&coupon).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update coupon"})
+		}
+
+		return c.JSON(coupon)
+	}
+}
+
+func DeleteCoupon(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result := db.Delete(&models.Coupon{}, c.Params("id"))
+		if result.Error != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete coupon"})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coupon not found"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Coupon deleted successfully"})
+	}
+}
+
+// GetCouponRedemptions lists the documents a coupon was applied to, with the count that
+// counts towards its limits
+func GetCouponRedemptions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var coupon models.Coupon
+		if err := db.Unscoped().First(&coupon, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coupon not found"})
+		}
+
+		var redemptions []models.CouponRedemption
+		if err := db.Where("coupon_id = ?", coupon.ID).Order("created_at desc").Find(&redemptions).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch redemptions"})
+		}
+
+		uses, err := countRedemptions(db, coupon.ID, 0)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count redemptions"})
+		}
+
+		return c.JSON(fiber.Map{"coupon": coupon, "uses": uses, "redemptions": redemptions})
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// createTestCoupon stores an active coupon
+func createTestCoupon(t *testing.T, db *gorm.DB, coupon models.Coupon) models.Coupon {
+	t.Helper()
+	coupon.Active = true
+	if err := db.Create(&coupon).Error; err != nil {
+		t.Fatal(err)
+	}
+	return coupon
+}
+
+// redeem applies coupon to a new document of owner in state
+func redeem(t *testing.T, db *gorm.DB, coupon models.Coupon, owner models.User, state models.DocumentState) models.Document {
+	t.Helper()
+	document := createTestDocument(t, db, owner, state)
+	discount := document.ApplyCoupon(&coupon)
+	if err := db.Save(&document).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.CouponRedemption{CouponID: coupon.ID, UserID: owner.ID, DocumentID: document.ID, Discount: discount}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func TestCountRedemptionsLeavesOutCancelledDocuments(t *testing.T) {
+	db := databasetest.Open(t)
+	coupon := createTestCoupon(t, db, models.Coupon{Code: "COUNT", Kind: models.CouponPercent, Value: 10})
+	first := createTestUser(t, db, "first", models.RoleUser)
+	second := createTestUser(t, db, "second", models.RoleUser)
+	redeem(t, db, coupon, first, models.StateQuoted)
+	redeem(t, db, coupon, first, models.StateCancelled)
+	redeem(t, db, coupon, second, models.StatePaid)
+
+	tests := []struct {
+		name  string
+		user  uint
+		count int64
+	}{
+		{"everyone", 0, 2},
+		{"one user", first.ID, 1},
+		{"another user", second.ID, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := countRedemptions(db, coupon.ID, tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.count {
+				t.Fatalf("counted %d, want %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestCheckCouponLimits(t *testing.T) {
+	db := databasetest.Open(t)
+	first := createTestUser(t, db, "first", models.RoleUser)
+	second := createTestUser(t, db, "second", models.RoleUser)
+
+	limited := createTestCoupon(t, db, models.Coupon{Code: "TWICE", Kind: models.CouponPercent, Value: 10, MaxUses: 2})
+	redeem(t, db, limited, first, models.StateQuoted)
+	if err := checkCouponLimits(db, &limited, second.ID); err != nil {
+		t.Fatalf("one use of two left: %v", err)
+	}
+	redeem(t, db, limited, second, models.StateQuoted)
+	if err := checkCouponLimits(db, &limited, second.ID); !errors.Is(err, models.ErrCouponUsedUp) {
+		t.Fatalf("used up coupon returned %v", err)
+	}
+
+	perUser := createTestCoupon(t, db, models.Coupon{Code: "ONCE", Kind: models.CouponPercent, Value: 10, MaxUsesPerUser: 1})
+	cancelled := redeem(t, db, perUser, first, models.StateQuoted)
+	if err := checkCouponLimits(db, &perUser, first.ID); !errors.Is(err, models.ErrCouponAlreadyUsed) {
+		t.Fatalf("second use by the same customer returned %v", err)
+	}
+	if err := checkCouponLimits(db, &perUser, second.ID); err != nil {
+		t.Fatalf("first use by another customer returned %v", err)
+	}
+
+	// Cancelling the document gives the use back
+	if err := db.Model(&cancelled).Update("state", models.StateCancelled).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := checkCouponLimits(db, &perUser, first.ID); err != nil {
+		t.Fatalf("use on a cancelled document still counted: %v", err)
+	}
+}
+
+func TestRequoteReappliesCoupon(t *testing.T) {
+	db := databasetest.Open(t)
+	admin := createTestUser(t, db, "admin", models.RoleAdmin)
+	owner := createTestUser(t, db, "owner", models.RoleUser)
+	percent := createTestCoupon(t, db, models.Coupon{Code: "TEN", Kind: models.CouponPercent, Value: 10})
+	fixed := createTestCoupon(t, db, models.Coupon{Code: "FIFTY", Kind: models.CouponFixed, Value: 50})
+
+	tests := []struct {
+		name       string
+		coupon     models.Coupon
+		amount     float64
+		discount   float64
+		couponCode string
+	}{
+		{"percent recomputed on the new price", percent, 300, 30, "TEN"},
+		{"fixed kept", fixed, 80, 50, "FIFTY"},
+		{"fixed dropped when the price falls to it", fixed, 50, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := redeem(t, db, tt.coupon, owner, models.StateSubmitted)
+
+			app := fiber.New()
+			app.Post("/documents/:id/quote", as(admin), RequoteDocument(db))
+			status, body := send(t, app, http.MethodPost, fmt.Sprintf("/documents/%d/quote", document.ID), fmt.Sprintf(`{"amount":%g}`, tt.amount))
+			if status != http.StatusOK {
+				t.Fatalf("status %d: %v", status, body)
+			}
+
+			var saved models.Document
+			db.First(&saved, document.ID)
+			if saved.Quote.Discount != tt.discount || saved.Quote.CouponCode != tt.couponCode || saved.Quote.Amount != tt.amount-tt.discount {
+				t.Fatalf("quote %+v", saved.Quote)
+			}
+
+			var redemption models.CouponRedemption
+			err := db.Where("document_id = ?", document.ID).First(&redemption).Error
+			if tt.couponCode == "" {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					t.Fatalf("redemption of a dropped coupon is kept: %v", err)
+				}
+				return
+			}
+			if err != nil || redemption.Discount != tt.discount {
+				t.Fatalf("redemption discount %.2f (%v), want %.2f", redemption.Discount, err, tt.discount)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// slaStates are the states in which the delivery of a paid document is tracked against its due date
+var slaStates = []models.DocumentState{models.StatePaid, models.StateAssigned, models.StateTranslating, models.StateInReview}
+
+// urgencyTurnaround returns the days promised by an urgency tier, 0 when it promises nothing
+func urgencyTurnaround(db *gorm.DB, code string) int {
+	if code == "" {
+		code = models.UrgencyStandard
+	}
+
+	var tier models.UrgencyTier
+	if err := db.Where("code = ?", code).First(&tier).Error; err != nil {
+		return 0
+	}
+	return tier.TurnaroundDays
+}
+
+// schedulePayment counts the due date of a document from its payment, it is saved with the
+// move to StatePaid. A deadline the customer asked for stays as it was at upload.
+func schedulePayment(db *gorm.DB, document *models.Document) {
+	document.ScheduleDelivery(time.Now(), models.DefaultWordsPerDay, urgencyTurnaround(db, document.Urgency))
+}
+
+// refreshEstimate re-estimates the delivery with the throughput of the translator who starts on
+// the document and saves it. The due date and its escalations are kept, only the promise matters.
+func refreshEstimate(db *gorm.DB, document *models.Document, translator *models.User) error {
+	document.EstimateFrom(time.Now(), translator.WordsPerDay)
+	return db.Model(document).Update("estimated_delivery", document.EstimatedDelivery).Error
+}
+
+// CheckDeadlines escalates paid documents that are about to miss or have missed their due
+// date. Every level is notified once, to the admins and the translator working on it.
+func CheckDeadlines(db *gorm.DB) {
+	now := time.Now()
+
+	var documents []models.Document
+	if err := db.Where("state IN ? AND due_at IS NOT NULL AND due_at < ? AND escalation_level < ?",
+		slaStates, now.Add(24*time.Hour), models.EscalationLongOverdue).
+		Or("state IN ? AND estimated_delivery > due_at AND escalation_level < ?", slaStates, models.EscalationAtRisk).
+		Find(&documents).Error; err != nil {
+		log.Printf("Failed to fetch documents due soon: %v", err)
+		return
+	}
+
+	for _, document := range documents {
+		level := document.EscalationAt(now)
+		if level <= document.EscalationLevel {
+			continue
+		}
+
+		// Only escalate if another run didn't get there first
+		result := db.Model(&models.Document{}).
+			Where("id = ? AND escalation_level = ?", document.ID, document.EscalationLevel).
+			Update("escalation_level", level)
+		if result.Error != nil {
+			log.Printf("Failed to escalate document ID %d: %v", document.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		message := escalationMessage(&document, level)
+		if err := notifyAdmins(&document, message, db); err != nil {
+			log.Printf("Failed to notify admins about document ID %d: %v", document.ID, err)
+		}
+		if document.TranslatorID != 0 {
+			if err := CreateNotification(document.TranslatorID, document.ID, message, db); err != nil {
+				log.Printf("Failed to notify translator ID %d: %v", document.TranslatorID, err)
+			}
+		}
+	}
+}
+
+func escalationMessage(document *models.Document, level int) string {
+	due := document.DueAt.Format("2006-01-02 15:04")
+	switch level {
+	case models.EscalationLongOverdue:
+		return fmt.Sprintf("Document \"%s\" is more than a day overdue, it was due %s.", document.Title, due)
+	case models.EscalationOverdue:
+		return fmt.Sprintf("Document \"%s\" is overdue, it was due %s.", document.Title, due)
+	default:
+		return fmt.Sprintf("Document \"%s\" is at risk of missing its due date of %s.", document.Title, due)
+	}
+}
+
+// GetSLADocuments lists paid documents that are at risk or overdue, most urgent first.
+// status can narrow it down to "at_risk" or "overdue".
+func GetSLADocuments(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		now := time.Now()
+		query := db.Where("state IN ? AND due_at IS NOT NULL", slaStates)
+
+		switch c.Query("status") {
+		case "overdue":
+			query = query.Where("due_at < ?", now)
+		case "at_risk":
+			query = query.Where("due_at >= ?", now).
+				Where("(due_at < ? OR estimated_delivery > due_at)", now.Add(24*time.Hour))
+		case "":
+			query = query.Where("(due_at < ? OR estimated_delivery > due_at)", now.Add(24*time.Hour))
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be 'at_risk' or 'overdue'"})
+		}
+
+		var documents []models.Document
+		if err := query.Order("due_at asc").Find(&documents).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch documents"})
+		}
+
+		type slaDocument struct {
+			models.Document
+			Overdue      bool    `json:"overdue"`
+			HoursToDue   float64 `json:"hours_to_due"` // Negative once overdue
+			CurrentLevel int     `json:"current_escalation"`
+		}
+		result := make([]slaDocument, 0, len(documents))
+		for _, document := range documents {
+			result = append(result, slaDocument{
+				Document:     document,
+				Overdue:      now.After(*document.DueAt),
+				HoursToDue:   document.DueAt.Sub(now).Hours(),
+				CurrentLevel: document.EscalationAt(now),
+			})
+		}
+
+		return c.JSON(result)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// setTurnaround makes the standard urgency tier promise days
+func setTurnaround(t *testing.T, db *gorm.DB, days int) {
+	t.Helper()
+	var tier models.UrgencyTier
+	if err := db.Where(models.UrgencyTier{Code: models.UrgencyStandard}).
+		Assign(models.UrgencyTier{Name: "Standard", Multiplier: 1, TurnaroundDays: days}).
+		FirstOrCreate(&tier).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApprovePaymentCountsDueDateFromPayment(t *testing.T) {
+	db := databasetest.Open(t)
+	setTurnaround(t, db, 5)
+	admin := createTestUser(t, db, "admin", models.RoleAdmin)
+	owner := createTestUser(t, db, "owner", models.RoleUser)
+
+	uploaded := time.Now().AddDate(0, 0, -20)
+	requested := time.Now().AddDate(0, 0, 30).Truncate(time.Second)
+	tests := []struct {
+		name     string
+		deadline *time.Time
+		due      time.Time
+	}{
+		{"urgency turnaround", nil, time.Now().AddDate(0, 0, 5)},
+		{"requested deadline", &requested, requested},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := createTestDocument(t, db, owner, models.StateQuoted)
+			document.RequestedDeadline = tt.deadline
+			document.ScheduleDelivery(uploaded, models.DefaultWordsPerDay, 5)
+			if err := db.Save(&document).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			app := fiber.New()
+			app.Post("/documents/:id/approve-payment", as(admin), ApprovePayment(db))
+			if status, body := send(t, app, http.MethodPost, fmt.Sprintf("/documents/%d/approve-payment", document.ID), ""); status != http.StatusOK {
+				t.Fatalf("status %d: %v", status, body)
+			}
+
+			var saved models.Document
+			db.First(&saved, document.ID)
+			if saved.DueAt == nil || saved.DueAt.Sub(tt.due).Abs() > time.Minute {
+				t.Fatalf("due %v, want %v", saved.DueAt, tt.due)
+			}
+		})
+	}
+}
+
+func TestAcceptingAssignmentKeepsDueDate(t *testing.T) {
+	db := databasetest.Open(t)
+	translator := createTestUser(t, db, "translator", models.RoleTranslator)
+	if err := db.Model(&translator).Update("words_per_day", 100).Error; err != nil {
+		t.Fatal(err)
+	}
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StateAssigned)
+
+	due := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	if err := db.Model(&document).Updates(map[string]interface{}{
+		"translator_id":    translator.ID,
+		"due_at":           due,
+		"escalation_level": models.EscalationAtRisk,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/documents/:id/approve", as(translator), ApproveAssignedDocument(db))
+	if status, body := send(t, app, http.MethodPost, fmt.Sprintf("/documents/%d/approve", document.ID), ""); status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+
+	var saved models.Document
+	db.First(&saved, document.ID)
+	if saved.DueAt == nil || !saved.DueAt.Equal(due) {
+		t.Fatalf("due date moved from %v to %v", due, saved.DueAt)
+	}
+	if saved.EscalationLevel != models.EscalationAtRisk {
+		t.Fatalf("escalation level reset to %d", saved.EscalationLevel)
+	}
+	// 500 words at 100 a day
+	if saved.EstimatedDelivery == nil || saved.EstimatedDelivery.Sub(time.Now().AddDate(0, 0, 5)).Abs() > time.Minute {
+		t.Fatalf("estimated %v", saved.EstimatedDelivery)
+	}
+}
+
+func TestCheckDeadlines(t *testing.T) {
+	db := databasetest.Open(t)
+	createTestUser(t, db, "admin", models.RoleAdmin)
+	translator := createTestUser(t, db, "translator", models.RoleTranslator)
+	owner := createTestUser(t, db, "owner", models.RoleUser)
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		state    models.DocumentState
+		due      time.Duration
+		estimate time.Duration
+		level    int
+		want     int
+	}{
+		{"due soon", models.StateTranslating, 2 * time.Hour, time.Hour, models.EscalationNone, models.EscalationAtRisk},
+		{"estimated late", models.StatePaid, 72 * time.Hour, 96 * time.Hour, models.EscalationNone, models.EscalationAtRisk},
+		{"overdue, warned before", models.StateInReview, -2 * time.Hour, time.Hour, models.EscalationAtRisk, models.EscalationOverdue},
+		{"long overdue", models.StateAssigned, -30 * time.Hour, time.Hour, models.EscalationNone, models.EscalationLongOverdue},
+		{"on time", models.StateTranslating, 72 * time.Hour, 24 * time.Hour, models.EscalationNone, models.EscalationNone},
+		{"not paid", models.StateQuoted, -30 * time.Hour, time.Hour, models.EscalationNone, models.EscalationNone},
+		{"delivered", models.StateDelivered, -30 * time.Hour, time.Hour, models.EscalationNone, models.EscalationNone},
+		{"already escalated", models.StateTranslating, -30 * time.Hour, time.Hour, models.EscalationLongOverdue, models.EscalationLongOverdue},
+	}
+
+	documents := make([]models.Document, len(tests))
+	for i, tt := range tests {
+		documents[i] = createTestDocument(t, db, owner, tt.state)
+		if err := db.Model(&documents[i]).Updates(map[string]interface{}{
+			"translator_id":      translator.ID,
+			"due_at":             now.Add(tt.due),
+			"estimated_delivery": now.Add(tt.estimate),
+			"escalation_level":   tt.level,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A second run finds nothing new to say
+	CheckDeadlines(db)
+	CheckDeadlines(db)
+
+	for i, tt := range tests {
+		var saved models.Document
+		db.First(&saved, documents[i].ID)
+		if saved.EscalationLevel != tt.want {
+			t.Errorf("%s: level %d, want %d", tt.name, saved.EscalationLevel, tt.want)
+		}
+
+		var notified int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND document_id = ?", translator.ID, documents[i].ID).Count(&notified)
+		if escalated := tt.want != tt.level; escalated && notified != 1 || !escalated && notified != 0 {
+			t.Errorf("%s: translator notified %d times", tt.name, notified)
+		}
+	}
+}
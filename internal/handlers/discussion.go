@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"strconv"
 	"translation-app-backend/internal/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,10 +10,10 @@ import (
 // GetDiscussions retrieves all discussion messages for a specific document
 func GetDiscussions(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		documentID := c.Params("id")
+		document := c.Locals("document").(*models.Document)
 
 		var discussions []models.Discussion
-		if err := db.Where("document_id = ?", documentID).Find(&discussions).Error; err != nil {
+		if err := db.Where("document_id = ?", document.ID).Find(&discussions).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch discussions"})
 		}
 
@@ -27,11 +26,7 @@ func PostDiscussion(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Locals("userID").(float64)
 		userRole, _ := c.Locals("userRole").(string)
-		documentIDStr := c.Params("id")
-		documentID, err := strconv.ParseUint(documentIDStr, 10, 32)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid document ID"})
-		}
+		document := c.Locals("document").(*models.Document)
 
 		var input struct {
 			Message string `json:"message"`
@@ -41,7 +36,7 @@ func PostDiscussion(db *gorm.DB) fiber.Handler {
 		}
 
 		discussion := models.Discussion{
-			DocumentID: uint(documentID),
+			DocumentID: document.ID,
 			UserID:     uint(userID),
 			Message:    input.Message,
 			UserRole:   userRole,
@@ -51,6 +46,8 @@ func PostDiscussion(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create discussion"})
 		}
 
+		publishDiscussion(db, document, discussion)
+
 		return c.JSON(discussion)
 	}
 }
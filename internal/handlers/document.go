@@ -1,29 +1,74 @@
 package handlers
 
 import (
-	"io"
+	"errors"
+	"log"
 	"strconv"
+	"time"
 	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+	"translation-app-backend/internal/textextract"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-// GetDocument retrieves the details of a specific document by ID
+// translatorDocument is a document as its translator sees it, without the customer's price
+// and payment. The nil fields shadow those of the document and are left out of the JSON.
+type translatorDocument struct {
+	*models.Document
+	Quote                  *struct{} `json:",omitempty"`
+	PaymentConfirmed       *struct{} `json:",omitempty"`
+	PaymentReceiptKey      *struct{} `json:",omitempty"`
+	PaymentReceiptFileName *struct{} `json:",omitempty"`
+}
+
+// translatorDocuments hides the price and payment of documents listed for a translator
+func translatorDocuments(documents []models.Document) []translatorDocument {
+	views := make([]translatorDocument, len(documents))
+	for i := range documents {
+		views[i] = translatorDocument{Document: &documents[i]}
+	}
+	return views
+}
+
+// GetDocument retrieves the details of a specific document by ID. The price and payment
+// are only shown to the owner and admins, like GetDocumentQuote.
 func GetDocument(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userID := c.Locals("userID")
-		documentID := c.Params("id")
-
-		var document models.Document
-		if err := db.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		document := c.Locals("document").(*models.Document)
+		if by := actorFrom(c); document.UserID != by.ID && by.Role != models.RoleAdmin {
+			return c.JSON(translatorDocument{Document: document})
 		}
 
 		return c.JSON(document)
 	}
 }
 
+// AcceptQuote lets the owner of an approved document accept its price so it can be paid
+func AcceptQuote(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if document.UserID != actorFrom(c).ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can accept its quote"})
+		}
+
+		if document.Quote.QuotedAt == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Document has not been priced yet"})
+		}
+
+		if err := changeDocumentState(db, document, models.StateQuoted, actorFrom(c), models.EventQuoteAccepted, ""); err != nil {
+			return stateChangeError(c, err)
+		}
+
+		if _, err := issueInvoice(db, document, models.InvoiceKindInvoice); err != nil {
+			log.Printf("Failed to issue invoice for document ID %d: %v", document.ID, err)
+		}
+
+		return c.JSON(fiber.Map{"message": "Quote accepted, please upload your payment receipt"})
+	}
+}
+
 // GetDocuments returns a list of documents for the authenticated user
 func GetDocuments(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -40,11 +85,19 @@ func GetDocuments(db *gorm.DB) fiber.Handler {
 }
 
 // UploadDocument handles the uploading of files along with additional data
-func UploadDocument(db *gorm.DB) fiber.Handler {
+func UploadDocument(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Check if userID is set in locals (set by middleware)
 		userID := c.Locals("userID").(float64)
 
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		if !user.Verified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Please verify your email address before uploading documents"})
+		}
+
 		// Parse the form
 		form, err := c.MultipartForm()
 		if err != nil {
@@ -58,15 +111,7 @@ func UploadDocument(db *gorm.DB) fiber.Handler {
 		}
 
 		file := files[0]
-		// Read the file content
-		fileContent, err := file.Open()
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open file: " + err.Error()})
-		}
-		defer fileContent.Close()
-
-		/// Read the file data
-		fileData, err := io.ReadAll(fileContent)
+		fileData, err := readUpload(file)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read file: " + err.Error()})
 		}
@@ -88,17 +133,46 @@ func UploadDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Number of pages must be a positive integer"})
 		}
 
+		// Urgency is optional and defaults to the standard turnaround
+		var urgency string
+		if values := form.Value["urgency"]; len(values) > 0 {
+			urgency = values[0]
+		}
+		urgency, _, err = urgencyMultiplier(db, urgency)
+		if errors.Is(err, errUnknownUrgency) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown urgency"})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to look up urgency"})
+		}
+
+		// The deadline is optional, an RFC 3339 time or a date
+		var deadline *time.Time
+		if values := form.Value["deadline"]; len(values) > 0 && values[0] != "" {
+			requested, err := parseTimeQuery(values[0])
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid deadline"})
+			}
+			if !requested.After(time.Now()) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Deadline must be in the future"})
+			}
+			deadline = &requested
+		}
+
 		doc := models.Document{
-			UserID:         uint(userID),
-			Title:          title,
-			Description:    description,
-			Category:       category,
-			FileContent:    fileData,
-			FileName:       file.Filename,
-			SourceLanguage: sourceLanguage,
-			TargetLanguage: targetLanguage,
-			NumberOfPages:  numberOfPagesInt,
-			Status:         "Pending", // Default status set when uploading a new document
+			UserID:            uint(userID),
+			Title:             title,
+			Description:       description,
+			Category:          category,
+			FileName:          file.Filename,
+			SourceLanguage:    sourceLanguage,
+			TargetLanguage:    targetLanguage,
+			NumberOfPages:     numberOfPagesInt,
+			Urgency:           urgency,
+			RequestedDeadline: deadline,
+			State:             models.StateSubmitted,
+			Status:            "Pending", // Default status set when uploading a new document
+			ApprovalStatus:    "Pending",
 		}
 
 		// Validate the document before saving
@@ -106,7 +180,34 @@ func UploadDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		// Count words for the price quote, unsupported or unreadable files are priced by page
+		if textextract.Supported(file.Filename) {
+			text, err := textextract.Extract(file.Filename, fileData)
+			if err != nil {
+				log.Printf("Failed to extract text from %s: %v", file.Filename, err)
+			} else {
+				doc.WordCount = textextract.CountWords(text)
+			}
+		}
+
+		// First schedule with the default throughput, the due date is counted again from the payment
+		// and the estimate when a translator starts
+		doc.ScheduleDelivery(time.Now(), models.DefaultWordsPerDay, urgencyTurnaround(db, urgency))
+
+		// Snapshot the price now, documents nothing applies to are quoted by an admin
+		doc.Quote, err = quoteDocument(db, &doc)
+		if err != nil && !errors.Is(err, errNoPrice) {
+			log.Printf("Failed to quote document %s: %v", file.Filename, err)
+		}
+
+		// Store the file outside the database, the document only keeps its key
+		doc.FileKey, err = saveBlob(c, store, "documents", file.Filename, fileData)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to store file: " + err.Error()})
+		}
+
 		if err := db.Create(&doc).Error; err != nil {
+			deleteBlob(c, store, doc.FileKey)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot create document" + err.Error()})
 		}
 
@@ -160,29 +261,26 @@ func GetTranslatorDocuments(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch documents"})
 		}
 
-		return c.JSON(documents)
+		return c.JSON(translatorDocuments(documents))
 	}
 }
 
 // DownloadTranslatedDocument handles the downloading of the translated document
-func DownloadTranslatedDocument(db *gorm.DB) fiber.Handler {
+func DownloadTranslatedDocument(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userID := c.Locals("userID")
-		documentID := c.Params("id")
-
-		var document models.Document
-		if err := db.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		document := c.Locals("document").(*models.Document)
+		if document.UserID != actorFrom(c).ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can download its translation here"})
 		}
 
-		if !document.PaymentConfirmed {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Payment not confirmed"})
+		if document.State != models.StateDelivered {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Translated document is not available yet"})
 		}
 
-		// Set the appropriate headers
-		c.Set("Content-Disposition", "attachment; filename="+document.TranslatedFileName)
-		c.Set("Content-Type", "application/octet-stream")
+		if document.TranslatedFileKey == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translated document not found"})
+		}
 
-		return c.Send(document.TranslatedFileContent)
+		return sendBlob(c, store, document.TranslatedFileKey, document.TranslatedFileName)
 	}
 }
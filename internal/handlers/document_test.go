@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"translation-app-backend/internal/models"
+)
+
+func TestTranslatorDocumentHidesPriceAndPayment(t *testing.T) {
+	document := models.Document{
+		Title:                  "Annual report",
+		PaymentConfirmed:       true,
+		PaymentReceiptKey:      "receipts/1.pdf",
+		PaymentReceiptFileName: "receipt.pdf",
+		Quote:                  models.Quote{Amount: 100},
+	}
+	document.ID = 1
+
+	raw, err := json.Marshal(translatorDocuments([]models.Document{document}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != 1 || decoded[0]["Title"] != "Annual report" || decoded[0]["ID"] != float64(1) {
+		t.Fatalf("translator view %s lost the document details", raw)
+	}
+	for _, field := range []string{"Quote", "PaymentConfirmed", "PaymentReceiptKey", "PaymentReceiptFileName"} {
+		if _, ok := decoded[0][field]; ok {
+			t.Errorf("translator view %s shows %s", raw, field)
+		}
+	}
+}
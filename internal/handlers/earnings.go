@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errNothingToPay is returned when a payout batch would be empty
+var errNothingToPay = errors.New("no unpaid earnings to pay out")
+
+// creditTranslator adds an entry to a translator's ledger. A document is credited once per
+// kind, so crediting again after a revision is delivered does nothing.
+func creditTranslator(db *gorm.DB, translatorID uint, document *models.Document, kind string, amount float64, description string) error {
+	if translatorID == 0 || amount <= 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LedgerEntry{
+		TranslatorID: translatorID,
+		DocumentID:   document.ID,
+		Kind:         kind,
+		Description:  description,
+		Amount:       amount,
+	}).Error
+}
+
+// creditTranslation credits the translator of a delivered document at their pay rate
+func creditTranslation(db *gorm.DB, document *models.Document) error {
+	var translator models.User
+	if err := db.First(&translator, document.TranslatorID).Error; err != nil {
+		return err
+	}
+
+	return creditTranslator(db, translator.ID, document, models.LedgerTranslation, translator.Earning(document, document.Quote.TaxRate), "Translation of "+document.Title)
+}
+
+// GetEarnings returns the translator's ledger for a period with totals, ?from=2026-01-01&to=2026-02-01
+func GetEarnings(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		query := db.Where("translator_id = ?", uint(userID))
+		if from := c.Query("from"); from != "" {
+			fromTime, err := parseTimeQuery(from)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'from' date"})
+			}
+			query = query.Where("created_at >= ?", fromTime)
+		}
+		if to := c.Query("to"); to != "" {
+			toTime, err := parseTimeQuery(to)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'to' date"})
+			}
+			query = query.Where("created_at < ?", toTime)
+		}
+
+		var entries []models.LedgerEntry
+		if err := query.Order("created_at desc").Find(&entries).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch earnings"})
+		}
+
+		var earned, paid float64
+		for _, entry := range entries {
+			earned += entry.Amount
+			if entry.PayoutID != nil {
+				paid += entry.Amount
+			}
+		}
+
+		// The balance covers every unpaid entry, not just the period
+		var balance float64
+		if err := db.Model(&models.LedgerEntry{}).
+			Where("translator_id = ? AND payout_id IS NULL", uint(userID)).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&balance).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compute balance"})
+		}
+
+		return c.JSON(fiber.Map{
+			"entries": entries,
+			"earned":  models.RoundMoney(earned),
+			"paid":    models.RoundMoney(paid),
+			"unpaid":  models.RoundMoney(earned - paid),
+			"balance": models.RoundMoney(balance),
+		})
+	}
+}
+
+// GetBankAccount returns the bank account the translator is paid to
+func GetBankAccount(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var account models.BankAccount
+		if err := db.Where("user_id = ?", uint(userID)).First(&account).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No bank account set"})
+		}
+
+		return c.JSON(account)
+	}
+}
+
+// UpdateBankAccount sets the bank account the translator is paid to
+func UpdateBankAccount(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var input struct {
+			BankName      string `json:"bank_name"`
+			AccountNumber string `json:"account_number"`
+			AccountName   string `json:"account_name"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+
+		var account models.BankAccount
+		if err := db.Where("user_id = ?", uint(userID)).FirstOrInit(&account).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load bank account"})
+		}
+		account.UserID = uint(userID)
+		account.BankName = input.BankName
+		account.AccountNumber = input.AccountNumber
+		account.AccountName = input.AccountName
+		if err := account.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Save(&account).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save bank account"})
+		}
+
+		return c.JSON(account)
+	}
+}
+
+// UpdatePayRate sets how an admin pays a translator for delivered documents
+func UpdatePayRate(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			PayRateType string  `json:"pay_rate_type"`
+			PayRate     float64 `json:"pay_rate"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		if err := models.ValidatePayRate(input.PayRateType, input.PayRate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var translator models.User
+		if err := db.Where("id = ? AND role = ?", c.Params("id"), models.RoleTranslator).First(&translator).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translator not found"})
+		}
+
+		if err := db.Model(&translator).Updates(map[string]interface{}{
+			"pay_rate_type": input.PayRateType,
+			"pay_rate":      input.PayRate,
+		}).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update pay rate"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Pay rate updated successfully"})
+	}
+}
+
+// CreatePayout pays out every unpaid ledger entry created before until, optionally only for
+// some translators. Translators without a bank account are left for a later batch.
+func CreatePayout(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+
+		var input struct {
+			Until         *time.Time `json:"until"`
+			TranslatorIDs []uint     `json:"translator_ids"`
+		}
+		if err := c.BodyParser(&input); err != nil && len(c.Body()) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request, until must be RFC 3339"})
+		}
+		until := time.Now()
+		if input.Until != nil {
+			until = *input.Until
+		}
+
+		var payout models.Payout
+		var paidTranslators []uint
+		err := db.Transaction(func(tx *gorm.DB) error {
+			query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("payout_id IS NULL AND created_at < ?", until).
+				Where("EXISTS (SELECT 1 FROM bank_accounts WHERE bank_accounts.user_id = ledger_entries.translator_id AND bank_accounts.deleted_at IS NULL)")
+			if len(input.TranslatorIDs) > 0 {
+				query = query.Where("translator_id IN ?", input.TranslatorIDs)
+			}
+
+			var entries []models.LedgerEntry
+			if err := query.Find(&entries).Error; err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return errNothingToPay
+			}
+
+			ids := make([]uint, len(entries))
+			seen := map[uint]bool{}
+			var total float64
+			for i, entry := range entries {
+				ids[i] = entry.ID
+				total += entry.Amount
+				if !seen[entry.TranslatorID] {
+					seen[entry.TranslatorID] = true
+					paidTranslators = append(paidTranslators, entry.TranslatorID)
+				}
+			}
+
+			payout = models.Payout{
+				CreatedByID: by.ID,
+				Until:       until,
+				Total:       models.RoundMoney(total),
+				EntryCount:  len(entries),
+			}
+			if err := tx.Create(&payout).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&models.LedgerEntry{}).Where("id IN ?", ids).Update("payout_id", payout.ID).Error
+		})
+		if errors.Is(err, errNothingToPay) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create payout"})
+		}
+
+		message := "Your earnings have been included in a payout and will be transferred to your bank account."
+		if err := CreateNotifications(paidTranslators, 0, message, db); err != nil {
+			log.Printf("Failed to notify translators about payout ID %d: %v", payout.ID, err)
+		}
+
+		return c.JSON(payout)
+	}
+}
+
+// GetPayouts lists the payout batches, newest first
+func GetPayouts(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var payouts []models.Payout
+		if err := db.Order("created_at desc").Find(&payouts).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch payouts"})
+		}
+
+		return c.JSON(payouts)
+	}
+}
+
+// ExportPayout sends a payout batch as a CSV with one bank transfer per translator
+func ExportPayout(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var payout models.Payout
+		if err := db.First(&payout, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Payout not found"})
+		}
+
+		var transfers []struct {
+			TranslatorID  uint
+			Username      string
+			Email         string
+			BankName      string
+			AccountNumber string
+			AccountName   string
+			Amount        float64
+			Entries       int
+		}
+		if err := db.Table("ledger_entries").
+			Select("ledger_entries.translator_id, users.username, users.email, bank_accounts.bank_name, bank_accounts.account_number, bank_accounts.account_name, SUM(ledger_entries.amount) AS amount, COUNT(*) AS entries").
+			Joins("JOIN users ON users.id = ledger_entries.translator_id").
+			Joins("LEFT JOIN bank_accounts ON bank_accounts.user_id = ledger_entries.translator_id AND bank_accounts.deleted_at IS NULL").
+			Where("ledger_entries.payout_id = ? AND ledger_entries.deleted_at IS NULL", payout.ID).
+			Group("ledger_entries.translator_id, users.username, users.email, bank_accounts.bank_name, bank_accounts.account_number, bank_accounts.account_name").
+			Order("ledger_entries.translator_id").
+			Scan(&transfers).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build export"})
+		}
+
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"translator_id", "name", "email", "bank_name", "account_number", "account_name", "amount", "entries", "reference"})
+		reference := "PAYOUT-" + strconv.FormatUint(uint64(payout.ID), 10)
+		for _, t := range transfers {
+			w.Write([]string{
+				strconv.FormatUint(uint64(t.TranslatorID), 10),
+				t.Username,
+				t.Email,
+				t.BankName,
+				t.AccountNumber,
+				t.AccountName,
+				formatMoney(models.RoundMoney(t.Amount)),
+				strconv.Itoa(t.Entries),
+				reference,
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build export"})
+		}
+
+		c.Set("Content-Disposition", "attachment; filename="+reference+".csv")
+		c.Set("Content-Type", "text/csv")
+		return c.Send(buf.Bytes())
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// credit stores an unpaid ledger entry of amount for translator
+func credit(t *testing.T, db *gorm.DB, translator models.User, documentID uint, amount float64) {
+	t.Helper()
+	if err := db.Create(&models.LedgerEntry{TranslatorID: translator.ID, DocumentID: documentID, Kind: models.LedgerTranslation, Amount: amount}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addBankAccount gives translator somewhere to be paid
+func addBankAccount(t *testing.T, db *gorm.DB, translator models.User) {
+	t.Helper()
+	if err := db.Create(&models.BankAccount{UserID: translator.ID, BankName: "BCA", AccountNumber: "123", AccountName: translator.Username}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func payoutApp(db *gorm.DB, admin models.User) *fiber.App {
+	app := fiber.New()
+	app.Post("/payouts", as(admin), CreatePayout(db))
+	app.Get("/payouts/:id/export", as(admin), ExportPayout(db))
+	return app
+}
+
+func TestCreditTranslationSharesPriceWithoutTax(t *testing.T) {
+	db := databasetest.Open(t)
+	translator := createTestUser(t, db, "translator", models.RoleTranslator)
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StateDelivered)
+	document.TranslatorID = translator.ID
+	document.Quote.Amount = 111
+	document.Quote.TaxRate = 11
+
+	if err := creditTranslation(db, &document); err != nil {
+		t.Fatal(err)
+	}
+	var entry models.LedgerEntry
+	if err := db.Where("document_id = ?", document.ID).First(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	if entry.Amount != 70 {
+		t.Fatalf("credited %.2f, want 70 of the 100 before tax", entry.Amount)
+	}
+}
+
+func TestCreatePayout(t *testing.T) {
+	db := databasetest.Open(t)
+	admin := createTestUser(t, db, "admin", models.RoleAdmin)
+	paid := createTestUser(t, db, "paid", models.RoleTranslator)
+	unbanked := createTestUser(t, db, "unbanked", models.RoleTranslator)
+	addBankAccount(t, db, paid)
+	credit(t, db, paid, 1, 70)
+	credit(t, db, paid, 2, 30.5)
+	credit(t, db, unbanked, 3, 50)
+
+	app := payoutApp(db, admin)
+	status, body := send(t, app, http.MethodPost, "/payouts", "")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	if body["Total"] != 100.5 || body["EntryCount"] != float64(2) {
+		t.Fatalf("payout %v, want the two entries of the translator with a bank account", body)
+	}
+
+	var unpaid int64
+	db.Model(&models.LedgerEntry{}).Where("translator_id = ? AND payout_id IS NULL", unbanked.ID).Count(&unpaid)
+	if unpaid != 1 {
+		t.Fatal("the entry of a translator without a bank account was paid out")
+	}
+
+	var notified int64
+	db.Model(&models.Notification{}).Where("user_id = ?", paid.ID).Count(&notified)
+	if notified != 1 {
+		t.Fatalf("translator notified %d times for one payout", notified)
+	}
+	db.Model(&models.Notification{}).Where("user_id = ?", unbanked.ID).Count(&notified)
+	if notified != 0 {
+		t.Fatal("a translator left out of the payout was notified")
+	}
+
+	// The entries are locked to the first payout, there is nothing left to pay
+	if status, body := send(t, app, http.MethodPost, "/payouts", ""); status != http.StatusConflict {
+		t.Fatalf("second payout returned %d: %v", status, body)
+	}
+}
+
+func TestCreatePayoutForSomeTranslators(t *testing.T) {
+	db := databasetest.Open(t)
+	admin := createTestUser(t, db, "admin", models.RoleAdmin)
+	chosen := createTestUser(t, db, "chosen", models.RoleTranslator)
+	other := createTestUser(t, db, "other", models.RoleTranslator)
+	addBankAccount(t, db, chosen)
+	addBankAccount(t, db, other)
+	credit(t, db, chosen, 1, 40)
+	credit(t, db, other, 2, 60)
+
+	status, body := send(t, payoutApp(db, admin), http.MethodPost, "/payouts", fmt.Sprintf(`{"translator_ids":[%d]}`, chosen.ID))
+	if status != http.StatusOK || body["Total"] != float64(40) {
+		t.Fatalf("status %d: %v", status, body)
+	}
+}
+
+func TestExportPayout(t *testing.T) {
+	db := databasetest.Open(t)
+	admin := createTestUser(t, db, "admin", models.RoleAdmin)
+	first := createTestUser(t, db, "first", models.RoleTranslator)
+	second := createTestUser(t, db, "second", models.RoleTranslator)
+	for _, translator := range []models.User{first, second} {
+		addBankAccount(t, db, translator)
+	}
+	credit(t, db, first, 1, 70)
+	credit(t, db, first, 2, 30.25)
+	credit(t, db, second, 3, 12)
+
+	app := payoutApp(db, admin)
+	status, body := send(t, app, http.MethodPost, "/payouts", "")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/payouts/%.0f/export", body["ID"]), nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/csv" {
+		t.Fatalf("export answered %d with %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	rows, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One transfer per translator after the header, with the entries summed
+	if len(rows) != 3 {
+		t.Fatalf("export has %d rows: %q", len(rows), raw)
+	}
+	want := [][2]string{{"100.25", "2"}, {"12.00", "1"}}
+	for i, row := range rows[1:] {
+		if row[6] != want[i][0] || row[7] != want[i][1] || row[3] != "BCA" || row[8] != fmt.Sprintf("PAYOUT-%.0f", body["ID"]) {
+			t.Errorf("transfer %d is %q", i, row)
+		}
+	}
+}
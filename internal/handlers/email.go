@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+	"translation-app-backend/internal/mailer"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	emailBatchSize   = 50
+	maxEmailAttempts = 8
+	maxEmailBackoff  = 6 * time.Hour
+)
+
+// appURL is the address of the web app that emails link back to
+func appURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return url
+	}
+	return "https://lekamantra.com"
+}
+
+// queueNotificationEmails puts an email in the outbox for every notification whose
+// type has a template and whose recipient opted in to email notifications
+func queueNotificationEmails(db *gorm.DB, notifications []models.Notification) error {
+	if len(notifications) == 0 || !mailer.HasTemplate(notifications[0].Type) {
+		return nil
+	}
+
+	userIDs := make([]uint, 0, len(notifications))
+	for _, notification := range notifications {
+		userIDs = append(userIDs, notification.UserID)
+	}
+
+	var users []models.User
+	if err := db.Where("id IN ? AND email_notifications = ?", userIDs, true).Find(&users).Error; err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	recipients := make(map[uint]models.User, len(users))
+	for _, user := range users {
+		recipients[user.ID] = user
+	}
+
+	// All notifications of a batch are about the same document
+	var document models.Document
+	if err := db.Select("id", "title").First(&document, notifications[0].DocumentID).Error; err != nil {
+		return err
+	}
+
+	var emails []models.EmailOutbox
+	for _, notification := range notifications {
+		user, ok := recipients[notification.UserID]
+		if !ok || user.Email == "" {
+			continue
+		}
+
+		email, err := renderEmail(user, notification.Type, mailer.TemplateData{
+			DocumentID:    document.ID,
+			DocumentTitle: document.Title,
+			Message:       notification.Message,
+		})
+		if err != nil {
+			return err
+		}
+
+		email.NotificationID = notification.ID
+		emails = append(emails, email)
+	}
+
+	if len(emails) == 0 {
+		return nil
+	}
+	return db.Create(&emails).Error
+}
+
+// renderEmail renders a template for a user into an outbox entry ready to be queued
+func renderEmail(user models.User, kind string, data mailer.TemplateData) (models.EmailOutbox, error) {
+	data.Username = user.Username
+	data.AppURL = appURL()
+
+	msg, err := mailer.Render(kind, data)
+	if err != nil {
+		return models.EmailOutbox{}, err
+	}
+
+	return models.EmailOutbox{
+		UserID:        user.ID,
+		To:            user.Email,
+		Subject:       msg.Subject,
+		TextBody:      msg.TextBody,
+		HTMLBody:      msg.HTMLBody,
+		Status:        models.EmailPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// queueEmail puts an email in the outbox regardless of the notification preferences of the
+// user, for account emails like verification and password reset
+func queueEmail(db *gorm.DB, user models.User, kind string, data mailer.TemplateData) error {
+	email, err := renderEmail(user, kind, data)
+	if err != nil {
+		return err
+	}
+	return db.Create(&email).Error
+}
+
+// emailBackoff is how long to wait before retrying an email that failed attempts times
+func emailBackoff(attempts int) time.Duration {
+	backoff := time.Minute << uint(attempts)
+	if backoff > maxEmailBackoff {
+		return maxEmailBackoff
+	}
+	return backoff
+}
+
+// DeliverQueuedEmails sends the emails that are due. Failed sends are retried with an
+// increasing delay and given up on after maxEmailAttempts.
+func DeliverQueuedEmails(db *gorm.DB, sender mailer.Sender) {
+	var emails []models.EmailOutbox
+	if err := db.Where("status = ? AND next_attempt_at <= ?", models.EmailPending, time.Now()).
+		Order("next_attempt_at asc").Limit(emailBatchSize).Find(&emails).Error; err != nil {
+		log.Printf("Failed to fetch queued emails: %v", err)
+		return
+	}
+
+	for _, email := range emails {
+		sendQueuedEmail(sender, &email)
+		if err := db.Save(&email).Error; err != nil {
+			log.Printf("Failed to update email ID %d: %v", email.ID, err)
+		}
+	}
+}
+
+// sendQueuedEmail makes one attempt to deliver an outbox email and records the outcome on it
+func sendQueuedEmail(sender mailer.Sender, email *models.EmailOutbox) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := sender.Send(ctx, mailer.Message{
+		To:       email.To,
+		Subject:  email.Subject,
+		TextBody: email.TextBody,
+		HTMLBody: email.HTMLBody,
+	})
+	cancel()
+
+	now := time.Now()
+	email.Attempts++
+	if err == nil {
+		email.Status = models.EmailSent
+		email.SentAt = &now
+		email.LastError = ""
+		return
+	}
+
+	log.Printf("Failed to send email ID %d to %s: %v", email.ID, email.To, err)
+	email.LastError = err.Error()
+	if email.Attempts >= maxEmailAttempts {
+		email.Status = models.EmailFailed
+	} else {
+		email.NextAttemptAt = now.Add(emailBackoff(email.Attempts))
+	}
+}
+
+// UpdateEmailNotifications lets a user opt in or out of notification emails
+func UpdateEmailNotifications(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var request struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+
+		if err := db.Model(&models.User{}).Where("id = ?", uint(userID)).Update("email_notifications", request.Enabled).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update email preferences"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Email preferences updated", "enabled": request.Enabled})
+	}
+}
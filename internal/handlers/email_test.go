@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"translation-app-backend/internal/mailer"
+	"translation-app-backend/internal/mailer/mailertest"
+	"translation-app-backend/internal/models"
+)
+
+func TestEmailBackoffGrowsUpToTheCap(t *testing.T) {
+	previous := time.Duration(0)
+	for attempts := 1; attempts < 20; attempts++ {
+		backoff := emailBackoff(attempts)
+		if backoff < previous {
+			t.Fatalf("backoff after %d attempts (%v) is shorter than after %d (%v)", attempts, backoff, attempts-1, previous)
+		}
+		if backoff > maxEmailBackoff {
+			t.Fatalf("backoff after %d attempts (%v) is over the cap", attempts, backoff)
+		}
+		previous = backoff
+	}
+	if emailBackoff(1) != 2*time.Minute || emailBackoff(19) != maxEmailBackoff {
+		t.Fatalf("unexpected backoffs %v and %v", emailBackoff(1), emailBackoff(19))
+	}
+}
+
+func smtpStub(t *testing.T) (*mailertest.Server, *mailer.SMTPSender) {
+	t.Helper()
+	server, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	host, _, _ := strings.Cut(server.Addr, ":")
+	return server, &mailer.SMTPSender{Addr: server.Addr, Host: host, From: "noreply@lekamantra.test"}
+}
+
+func queuedEmail() models.EmailOutbox {
+	return models.EmailOutbox{
+		To:            "ayu@example.test",
+		Subject:       "Your translation is ready",
+		TextBody:      "Hi Ayu",
+		Status:        models.EmailPending,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+func TestSendQueuedEmailRetriesAfterTemporaryFailure(t *testing.T) {
+	server, sender := smtpStub(t)
+	server.RejectNext(1)
+	email := queuedEmail()
+
+	before := time.Now()
+	sendQueuedEmail(sender, &email)
+	if email.Status != models.EmailPending || email.Attempts != 1 || email.LastError == "" {
+		t.Fatalf("after a rejected attempt: status %q, attempts %d, error %q", email.Status, email.Attempts, email.LastError)
+	}
+	if email.NextAttemptAt.Before(before.Add(emailBackoff(1))) || email.NextAttemptAt.After(time.Now().Add(emailBackoff(1))) {
+		t.Fatalf("next attempt at %v, want about %v from now", email.NextAttemptAt, emailBackoff(1))
+	}
+
+	sendQueuedEmail(sender, &email)
+	if email.Status != models.EmailSent || email.Attempts != 2 || email.SentAt == nil || email.LastError != "" {
+		t.Fatalf("after a delivered attempt: status %q, attempts %d, error %q", email.Status, email.Attempts, email.LastError)
+	}
+	if received := server.Received(); len(received) != 1 || received[0].To[0] != email.To {
+		t.Fatalf("server received %+v", received)
+	}
+}
+
+func TestSendQueuedEmailGivesUpAfterMaxAttempts(t *testing.T) {
+	server, sender := smtpStub(t)
+	server.RejectNext(maxEmailAttempts)
+	email := queuedEmail()
+
+	for i := 1; i <= maxEmailAttempts; i++ {
+		sendQueuedEmail(sender, &email)
+		want := models.EmailPending
+		if i == maxEmailAttempts {
+			want = models.EmailFailed
+		}
+		if email.Status != want {
+			t.Fatalf("after attempt %d status is %q, want %q", i, email.Status, want)
+		}
+	}
+	if len(server.Received()) != 0 {
+		t.Fatal("server accepted a rejected message")
+	}
+}
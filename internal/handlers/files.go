@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"translation-app-backend/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// saveUpload copies an uploaded multipart file into the blob store and returns its key
+func saveUpload(c *fiber.Ctx, store storage.BlobStore, prefix string, file *multipart.FileHeader) (string, error) {
+	fileContent, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer fileContent.Close()
+
+	key := storage.NewKey(prefix, file.Filename)
+	if err := store.Put(c.UserContext(), key, fileContent, file.Size, file.Header.Get("Content-Type")); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// readUpload reads a whole uploaded file into memory, uploads are capped by the app BodyLimit
+func readUpload(file *multipart.FileHeader) ([]byte, error) {
+	fileContent, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fileContent.Close()
+
+	return io.ReadAll(fileContent)
+}
+
+// saveBlob stores data that has already been read into memory and returns its key
+func saveBlob(c *fiber.Ctx, store storage.BlobStore, prefix string, fileName string, data []byte) (string, error) {
+	key := storage.NewKey(prefix, fileName)
+	if err := store.Put(c.UserContext(), key, bytes.NewReader(data), int64(len(data)), ""); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// sendBlob streams a stored blob to the client as a file attachment
+func sendBlob(c *fiber.Ctx, store storage.BlobStore, key string, fileName string) error {
+	blob, err := store.Get(c.UserContext(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "File not found"})
+	}
+	if err != nil {
+		log.Printf("Failed to read blob %s: %v", key, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read file"})
+	}
+
+	// Set the appropriate headers
+	c.Set("Content-Disposition", "attachment; filename="+fileName)
+	c.Set("Content-Type", "application/octet-stream")
+
+	// The stream is closed by fasthttp once the response has been written
+	return c.SendStream(blob)
+}
+
+// maxBlobRead caps blobs read into memory, stored files came through the 10MB BodyLimit
+const maxBlobRead = 16 << 20
+
+// readBlob reads a whole stored blob into memory
+func readBlob(c *fiber.Ctx, store storage.BlobStore, key string) ([]byte, error) {
+	blob, err := store.Get(c.UserContext(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	data, err := io.ReadAll(io.LimitReader(blob, maxBlobRead+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBlobRead {
+		return nil, errors.New("blob " + key + " is too large to read into memory")
+	}
+	return data, nil
+}
+
+// deleteBlob removes a blob that is no longer referenced, logging instead of failing the request
+func deleteBlob(c *fiber.Ctx, store storage.BlobStore, key string) {
+	if key == "" {
+		return
+	}
+	if err := store.Delete(c.UserContext(), key); err != nil {
+		log.Printf("Failed to delete blob %s: %v", key, err)
+	}
+}
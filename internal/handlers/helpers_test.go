@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// createTestUser stores a verified user, translators are vetted and speak en to id
+func createTestUser(t *testing.T, db *gorm.DB, name string, role string) models.User {
+	t.Helper()
+	user := models.User{
+		Username: name,
+		Email:    fmt.Sprintf("%s-%d@example.test", name, time.Now().UnixNano()),
+		Role:     role,
+		Verified: true,
+	}
+	if role == models.RoleTranslator {
+		user.VettingStatus = models.VettingApproved
+		user.ProficientLanguages = []string{"en", "id"}
+		user.Categories = []string{models.CategoryGeneral}
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestDocument stores a document of owner in state, quoted at 100
+func createTestDocument(t *testing.T, db *gorm.DB, owner models.User, state models.DocumentState) models.Document {
+	t.Helper()
+	now := time.Now()
+	document := models.Document{
+		UserID:         owner.ID,
+		State:          state,
+		Title:          "Annual report",
+		Category:       models.CategoryGeneral,
+		SourceLanguage: "en",
+		TargetLanguage: "id",
+		NumberOfPages:  2,
+		WordCount:      500,
+		Quote:          models.Quote{Amount: 100, QuotedAt: &now},
+	}
+	if err := db.Create(&document).Error; err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+// as stands in for the Authenticated middleware, acting as user
+func as(user models.User) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("userID", float64(user.ID))
+		c.Locals("userRole", user.Role)
+		return c.Next()
+	}
+}
+
+// send makes a request to app and decodes a JSON object response
+func send(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(raw, &decoded)
+	return resp.StatusCode, decoded
+}
+
+// createTestSession stores an active session of user
+func createTestSession(t *testing.T, db *gorm.DB, user models.User) models.Session {
+	t.Helper()
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: fmt.Sprintf("test-%d-%d", user.ID, time.Now().UnixNano()),
+		ExpiresAt:        time.Now().Add(time.Hour),
+		LastUsedAt:       time.Now(),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// activeSessions counts the sessions of user that haven't been revoked
+func activeSessions(t *testing.T, db *gorm.DB, user models.User) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetDocumentHistory returns the audit trail of a document to its owner, its translator or an admin
+func GetDocumentHistory(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+
+		var events []models.DocumentEvent
+		if err := db.Where("document_id = ?", document.ID).Order("created_at asc, id asc").Find(&events).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch document history"})
+		}
+
+		return c.JSON(events)
+	}
+}
+
+// SearchDocumentEvents lets admins search the audit trail across all documents
+func SearchDocumentEvents(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := db.Model(&models.DocumentEvent{})
+
+		if documentID := c.QueryInt("document_id"); documentID > 0 {
+			query = query.Where("document_id = ?", documentID)
+		}
+		if actorID := c.QueryInt("actor_id"); actorID > 0 {
+			query = query.Where("actor_id = ?", actorID)
+		}
+		if translatorID := c.QueryInt("translator_id"); translatorID > 0 {
+			query = query.Where("translator_id = ?", translatorID)
+		}
+		if role := c.Query("role"); role != "" {
+			query = query.Where("actor_role = ?", role)
+		}
+		if action := c.Query("action"); action != "" {
+			query = query.Where("action = ?", action)
+		}
+		if from := c.Query("from"); from != "" {
+			fromTime, err := parseTimeQuery(from)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'from' date"})
+			}
+			query = query.Where("created_at >= ?", fromTime)
+		}
+		if to := c.Query("to"); to != "" {
+			toTime, err := parseTimeQuery(to)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'to' date"})
+			}
+			query = query.Where("created_at < ?", toTime)
+		}
+
+		limit := c.QueryInt("limit", 100)
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+		offset := c.QueryInt("offset")
+		if offset < 0 {
+			offset = 0
+		}
+
+		var events []models.DocumentEvent
+		if err := query.Order("created_at desc, id desc").Limit(limit).Offset(offset).Find(&events).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to search audit trail"})
+		}
+
+		return c.JSON(events)
+	}
+}
+
+// parseTimeQuery accepts either a full RFC 3339 timestamp or a plain date
+func parseTimeQuery(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
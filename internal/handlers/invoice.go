@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/payments"
+	"translation-app-backend/internal/pdf"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invoiceIssuer is the business name printed on invoices, set with INVOICE_ISSUER
+func invoiceIssuer() string {
+	if issuer := os.Getenv("INVOICE_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Lekamantra"
+}
+
+// taxRate returns the percent of tax included in new quotes, quoted documents keep theirs in Quote.TaxRate
+func taxRate(db *gorm.DB) float64 {
+	var settings models.Settings
+	if err := db.First(&settings).Error; err != nil {
+		return 0
+	}
+	return settings.TaxRate
+}
+
+// nextInvoiceNumber takes the next number of a series. The sequence row stays locked until
+// the transaction ends, so numbers have no gaps or duplicates.
+func nextInvoiceNumber(tx *gorm.DB, series string) (string, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InvoiceSequence{Series: series}).Error; err != nil {
+		return "", err
+	}
+
+	var sequence models.InvoiceSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("series = ?", series).First(&sequence).Error; err != nil {
+		return "", err
+	}
+
+	sequence.Last++
+	if err := tx.Model(&sequence).Where("series = ?", series).Update("last", sequence.Last).Error; err != nil {
+		return "", err
+	}
+	return models.InvoiceNumber(series, sequence.Last), nil
+}
+
+// issueInvoice issues an invoice or receipt for a document. A document gets at most one
+// of each kind, asking again returns the one already issued. Both are taxed at the rate the
+// quote was made with, so a change of rate in between doesn't split them.
+func issueInvoice(db *gorm.DB, document *models.Document, kind string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("document_id = ? AND kind = ?", document.ID, kind).First(&invoice).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var customer models.User
+		if err := tx.First(&customer, document.UserID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		invoice = models.NewInvoice(kind, document, &customer, document.Quote.TaxRate, payments.Currency())
+		invoice.IssuedAt = now
+		if kind == models.InvoiceKindReceipt {
+			invoice.PaidAt = &now
+		}
+
+		invoice.Number, err = nextInvoiceNumber(tx, models.InvoiceSeries(kind, now))
+		if err != nil {
+			return err
+		}
+		return tx.Create(&invoice).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// formatQuantity writes the billed quantity, e.g. "1,250 words"
+func formatQuantity(invoice *models.Invoice) string {
+	if invoice.Unit == "flat" {
+		return "1"
+	}
+
+	digits := strconv.Itoa(invoice.Quantity)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String() + " " + invoice.Unit + "s"
+}
+
+// invoicePDF lays out an invoice or receipt on a single A4 page
+func invoicePDF(invoice *models.Invoice) []byte {
+	doc := pdf.New()
+	page := doc.AddPage()
+	const left, right = 50.0, pdf.PageWidth - 50
+	y := pdf.PageHeight - 60
+
+	title := "INVOICE"
+	if invoice.Kind == models.InvoiceKindReceipt {
+		title = "PAYMENT RECEIPT"
+	}
+	page.Text(left, y, 20, pdf.Bold, invoiceIssuer())
+	page.TextRight(right, y, 20, pdf.Bold, title)
+
+	y -= 40
+	page.Text(left, y, 10, pdf.Bold, "Billed to")
+	page.TextRight(right, y, 10, pdf.Regular, "Number: "+invoice.Number)
+	y -= 15
+	page.Text(left, y, 10, pdf.Regular, invoice.CustomerName)
+	page.TextRight(right, y, 10, pdf.Regular, "Issued: "+invoice.IssuedAt.Format("2 January 2006"))
+	y -= 15
+	page.Text(left, y, 10, pdf.Regular, invoice.CustomerEmail)
+	if invoice.PaidAt != nil {
+		page.TextRight(right, y, 10, pdf.Regular, "Paid: "+invoice.PaidAt.Format("2 January 2006"))
+	}
+
+	// Line item
+	y -= 45
+	page.Text(left, y, 10, pdf.Bold, "Description")
+	page.TextRight(340, y, 10, pdf.Bold, "Quantity")
+	page.TextRight(440, y, 10, pdf.Bold, "Unit price")
+	// Quoted prices include tax, the line and discount are shown as quoted and split below
+	page.TextRight(right, y, 10, pdf.Bold, "Amount (incl. tax)")
+	y -= 8
+	page.Line(left, y, right, y)
+	y -= 16
+	page.Text(left, y, 10, pdf.Regular, "Translation: "+invoice.DocumentTitle)
+	page.TextRight(340, y, 10, pdf.Regular, formatQuantity(invoice))
+	page.TextRight(440, y, 10, pdf.Regular, formatMoney(invoice.UnitPrice))
+	page.TextRight(right, y, 10, pdf.Regular, formatMoney(models.RoundMoney(invoice.Total+invoice.Discount)))
+	y -= 14
+	page.Text(left, y, 9, pdf.Regular, invoice.SourceLanguage+" to "+invoice.TargetLanguage)
+	y -= 10
+	page.Line(left, y, right, y)
+
+	// Totals
+	if invoice.Discount > 0 {
+		y -= 20
+		page.TextRight(440, y, 10, pdf.Regular, "Discount ("+invoice.CouponCode+")")
+		page.TextRight(right, y, 10, pdf.Regular, "-"+formatMoney(invoice.Discount))
+	}
+	y -= 20
+	page.TextRight(440, y, 10, pdf.Regular, "Subtotal")
+	page.TextRight(right, y, 10, pdf.Regular, formatMoney(invoice.Subtotal))
+	y -= 15
+	page.TextRight(440, y, 10, pdf.Regular, "Tax ("+strconv.FormatFloat(invoice.TaxRate, 'f', -1, 64)+"%)")
+	page.TextRight(right, y, 10, pdf.Regular, formatMoney(invoice.Tax))
+	y -= 18
+	page.TextRight(440, y, 11, pdf.Bold, "Total "+invoice.Currency)
+	page.TextRight(right, y, 11, pdf.Bold, formatMoney(invoice.Total))
+
+	if invoice.Kind == models.InvoiceKindReceipt {
+		y -= 40
+		page.Text(left, y, 10, pdf.Regular, "This receipt confirms the payment of the amount above. Thank you.")
+	}
+
+	return doc.Bytes()
+}
+
+// canSeeInvoices lets the owner of the document and admins see its invoices
+func canSeeInvoices(document *models.Document, by actor) bool {
+	return by.Role == models.RoleAdmin || document.UserID == by.ID
+}
+
+// GetDocumentInvoices lists the invoice and receipt issued for a document
+func GetDocumentInvoices(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if !canSeeInvoices(document, actorFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can see its invoices"})
+		}
+
+		var invoices []models.Invoice
+		if err := db.Where("document_id = ?", document.ID).Order("issued_at asc").Find(&invoices).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch invoices"})
+		}
+
+		return c.JSON(invoices)
+	}
+}
+
+// DownloadInvoice sends an invoice or receipt of a document as a PDF
+func DownloadInvoice(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if !canSeeInvoices(document, actorFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can see its invoices"})
+		}
+
+		var invoice models.Invoice
+		if err := db.Where("document_id = ? AND number = ?", document.ID, c.Params("number")).First(&invoice).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Invoice not found"})
+		}
+
+		c.Set("Content-Disposition", "attachment; filename="+invoice.Number+".pdf")
+		c.Set("Content-Type", "application/pdf")
+		return c.Send(invoicePDF(&invoice))
+	}
+}
+
+// GetInvoices lets admins list issued invoices and receipts to reconcile payments,
+// filtered by kind and issue date
+func GetInvoices(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := db.Model(&models.Invoice{})
+
+		if kind := c.Query("kind"); kind != "" {
+			query = query.Where("kind = ?", kind)
+		}
+		if from := c.Query("from"); from != "" {
+			fromTime, err := parseTimeQuery(from)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'from' date"})
+			}
+			query = query.Where("issued_at >= ?", fromTime)
+		}
+		if to := c.Query("to"); to != "" {
+			toTime, err := parseTimeQuery(to)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'to' date"})
+			}
+			query = query.Where("issued_at < ?", toTime)
+		}
+
+		var invoices []models.Invoice
+		if err := query.Order("issued_at desc, id desc").Find(&invoices).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch invoices"})
+		}
+
+		return c.JSON(invoices)
+	}
+}
+
+// UpdateTaxRate sets the percent of tax included in new quotes
+func UpdateTaxRate(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			TaxRate float64 `json:"tax_rate"`
+		}
+
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+		if input.TaxRate < 0 || input.TaxRate > 100 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tax_rate must be between 0 and 100"})
+		}
+
+		var settings models.Settings
+		if err := db.FirstOrCreate(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load settings"})
+		}
+
+		settings.TaxRate = input.TaxRate
+		if err := db.Save(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update settings"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Tax rate updated successfully", "tax_rate": settings.TaxRate})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+)
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		invoice models.Invoice
+		want    string
+	}{
+		{models.Invoice{Quantity: 1250, Unit: "word"}, "1,250 words"},
+		{models.Invoice{Quantity: 1234567, Unit: "word"}, "1,234,567 words"},
+		{models.Invoice{Quantity: 12, Unit: "page"}, "12 pages"},
+		{models.Invoice{Quantity: 1, Unit: "flat"}, "1"},
+	}
+	for _, tt := range tests {
+		if got := formatQuantity(&tt.invoice); got != tt.want {
+			t.Errorf("formatQuantity(%d %s) = %q, want %q", tt.invoice.Quantity, tt.invoice.Unit, got, tt.want)
+		}
+	}
+}
+
+func TestInvoicePDF(t *testing.T) {
+	paid := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	invoice := models.Invoice{
+		Number:        "RCP-2026-00007",
+		Kind:          models.InvoiceKindReceipt,
+		CustomerName:  "Dédé (Jakarta)",
+		DocumentTitle: `Contract \ draft`,
+		Quantity:      1,
+		Unit:          "flat",
+		UnitPrice:     111,
+		Subtotal:      100,
+		TaxRate:       11,
+		Tax:           11,
+		Total:         111,
+		Currency:      "IDR",
+		IssuedAt:      paid,
+		PaidAt:        &paid,
+	}
+	data := invoicePDF(&invoice)
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("not a complete PDF")
+	}
+	for _, want := range []string{
+		"(PAYMENT RECEIPT)",
+		"(Number: RCP-2026-00007)",
+		`(D\351d\351 \(Jakarta\))`,
+		`(Translation: Contract \\ draft)`,
+		"(Tax \\(11%\\))",
+		"(111.00)",
+		"(Paid: 4 March 2026)",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("PDF doesn't show %s", want)
+		}
+	}
+}
+
+func TestNextInvoiceNumber(t *testing.T) {
+	db := databasetest.Open(t)
+
+	for _, want := range []string{"TST-2026-00001", "TST-2026-00002", "TST-2026-00003"} {
+		if got, err := nextInvoiceNumber(db, "TST-2026"); err != nil || got != want {
+			t.Fatalf("got %q, %v, want %q", got, err, want)
+		}
+	}
+	// Every series counts on its own
+	if got, err := nextInvoiceNumber(db, "TST-2027"); err != nil || got != "TST-2027-00001" {
+		t.Fatalf("first number of a new series was %q, %v", got, err)
+	}
+}
+
+func TestIssueInvoiceKeepsTheQuotedTaxRate(t *testing.T) {
+	db := databasetest.Open(t)
+	var settings models.Settings
+	if err := db.FirstOrCreate(&settings).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StateQuoted)
+	document.Quote.Amount = 111
+	document.Quote.TaxRate = 11
+	if err := db.Save(&document).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	invoice, err := issueInvoice(db, &document, models.InvoiceKindInvoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The rate changes before the payment comes in
+	db.Model(&settings).Update("tax_rate", 12)
+	receipt, err := issueInvoice(db, &document, models.InvoiceKindReceipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, issued := range []*models.Invoice{invoice, receipt} {
+		if issued.TaxRate != 11 || issued.Subtotal != 100 || issued.Tax != 11 {
+			t.Errorf("%s taxed at %v%%: %.2f + %.2f", issued.Kind, issued.TaxRate, issued.Subtotal, issued.Tax)
+		}
+	}
+	if receipt.PaidAt == nil || invoice.PaidAt != nil {
+		t.Fatal("only the receipt is paid")
+	}
+
+	again, err := issueInvoice(db, &document, models.InvoiceKindInvoice)
+	if err != nil || again.Number != invoice.Number {
+		t.Fatalf("issuing again gave %v, %v instead of %s", again, err, invoice.Number)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// actor identifies who performed an action on a document
+type actor struct {
+	ID   uint
+	Role string
+}
+
+// systemActor is used for changes made by background jobs
+var systemActor = actor{Role: models.RoleSystem}
+
+// actorFrom returns the authenticated user of the request
+func actorFrom(c *fiber.Ctx) actor {
+	userID, _ := c.Locals("userID").(float64)
+	role, _ := c.Locals("userRole").(string)
+	return actor{ID: uint(userID), Role: role}
+}
+
+// changeDocumentState moves a document through the lifecycle, persists it and records the
+// change in the audit trail. The update only applies if nobody changed the state in the meantime.
+func changeDocumentState(db *gorm.DB, document *models.Document, to models.DocumentState, by actor, action string, reason string) error {
+	return changeDocumentStateWithReason(db, document, to, by, action, "", reason)
+}
+
+// changeDocumentStateWithReason is changeDocumentState for rejections and declines, which
+// record one of models.ReasonCodes next to the free-text reason
+func changeDocumentStateWithReason(db *gorm.DB, document *models.Document, to models.DocumentState, by actor, action string, reasonCode string, reason string) error {
+	from := document.State
+	// Some transitions release the translator, the event still names who was involved
+	translatorID := document.TranslatorID
+	if err := document.TransitionTo(to); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(document).Where("state = ?", from).Select("*").Updates(document)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: document was updated concurrently", models.ErrInvalidTransition)
+		}
+
+		return tx.Create(&models.DocumentEvent{
+			DocumentID:   document.ID,
+			ActorID:      by.ID,
+			ActorRole:    by.Role,
+			Action:       action,
+			FromState:    from,
+			ToState:      to,
+			TranslatorID: translatorID,
+			ReasonCode:   reasonCode,
+			Reason:       reason,
+		}).Error
+	})
+}
+
+// recordDocumentEvent adds an audit entry for an action that doesn't change the document state
+func recordDocumentEvent(db *gorm.DB, document *models.Document, by actor, action string, reason string) error {
+	return db.Create(&models.DocumentEvent{
+		DocumentID:   document.ID,
+		ActorID:      by.ID,
+		ActorRole:    by.Role,
+		Action:       action,
+		ToState:      document.State,
+		TranslatorID: document.TranslatorID,
+		Reason:       reason,
+	}).Error
+}
+
+// stateChangeError responds with 409 for lifecycle violations and 500 for anything else
+func stateChangeError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, models.ErrInvalidTransition) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+}
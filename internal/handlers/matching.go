@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	errNoTranslatorAvailable = errors.New("no translator available for this document")
+	errTranslatorAtCapacity  = errors.New("translator has reached their maximum number of concurrent jobs")
+	errTranslatorAway        = errors.New("translator is not available right now")
+)
+
+// activeJobStates are the states in which a document takes up one of the translator's job slots
+var activeJobStates = []models.DocumentState{models.StateAssigned, models.StateTranslating, models.StateInReview}
+
+// findTranslators returns vetted translators proficient in both languages and the category
+// who have a free job slot and are not away, best rated first and, among equally rated ones,
+// the least busy first
+func findTranslators(db *gorm.DB, sourceLanguage, targetLanguage, category string, exclude []uint) ([]TranslatorWithRating, error) {
+	now := time.Now()
+	query := db.Table("users").
+		Select("users.*, COALESCE(r.average_rating, 0) AS average_rating, COALESCE(w.workload, 0) AS workload").
+		Joins("LEFT JOIN (SELECT translator_id, AVG(rating) AS average_rating FROM ratings WHERE deleted_at IS NULL GROUP BY translator_id) r ON r.translator_id = users.id").
+		Joins("LEFT JOIN (SELECT translator_id, COUNT(*) AS workload FROM documents WHERE state IN ? AND deleted_at IS NULL GROUP BY translator_id) w ON w.translator_id = users.id",
+			activeJobStates).
+		Where("users.role = ? AND users.deleted_at IS NULL", models.RoleTranslator).
+		Where("users.vetting_status = ? AND users.available", models.VettingApproved).
+		Where("COALESCE(w.workload, 0) < users.max_concurrent_jobs").
+		Where("NOT EXISTS (SELECT 1 FROM time_offs t WHERE t.user_id = users.id AND t.deleted_at IS NULL AND ? >= t.starts_at AND ? < t.ends_at)", now, now).
+		Where("ARRAY[?] <@ users.proficient_languages", sourceLanguage).
+		Where("ARRAY[?] <@ users.proficient_languages", targetLanguage).
+		Where("ARRAY[?] <@ users.categories", category)
+
+	if len(exclude) > 0 {
+		query = query.Where("users.id NOT IN ?", exclude)
+	}
+
+	var translators []TranslatorWithRating
+	if err := query.Order("average_rating DESC, workload ASC, users.id ASC").Scan(&translators).Error; err != nil {
+		return nil, err
+	}
+
+	return filterWorkingTranslators(db, translators, now)
+}
+
+// filterWorkingTranslators leaves out translators who set working hours that don't include t
+func filterWorkingTranslators(db *gorm.DB, translators []TranslatorWithRating, t time.Time) ([]TranslatorWithRating, error) {
+	if len(translators) == 0 {
+		return translators, nil
+	}
+
+	ids := make([]uint, 0, len(translators))
+	for _, translator := range translators {
+		ids = append(ids, translator.ID)
+	}
+
+	var slots []models.WorkingHours
+	if err := db.Where("user_id IN ?", ids).Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	hours := make(map[uint][]models.WorkingHours)
+	for _, slot := range slots {
+		hours[slot.UserID] = append(hours[slot.UserID], slot)
+	}
+
+	working := translators[:0]
+	for _, translator := range translators {
+		if models.WorkingAt(hours[translator.ID], translator.TimeZone, t) {
+			working = append(working, translator)
+		}
+	}
+	return working, nil
+}
+
+// checkTranslatorAvailable tells whether a translator can take one more document right now
+func checkTranslatorAvailable(db *gorm.DB, translator *models.User) error {
+	if !translator.Available {
+		return errTranslatorAway
+	}
+
+	var workload int64
+	if err := db.Model(&models.Document{}).Where("translator_id = ? AND state IN ?", translator.ID, activeJobStates).Count(&workload).Error; err != nil {
+		return err
+	}
+	if workload >= int64(translator.MaxConcurrentJobs) {
+		return errTranslatorAtCapacity
+	}
+
+	now := time.Now()
+	var away int64
+	if err := db.Model(&models.TimeOff{}).Where("user_id = ? AND ? >= starts_at AND ? < ends_at", translator.ID, now, now).Count(&away).Error; err != nil {
+		return err
+	}
+	if away > 0 {
+		return errTranslatorAway
+	}
+
+	var hours []models.WorkingHours
+	if err := db.Where("user_id = ?", translator.ID).Find(&hours).Error; err != nil {
+		return err
+	}
+	if !models.WorkingAt(hours, translator.TimeZone, now) {
+		return errTranslatorAway
+	}
+
+	return nil
+}
+
+// autoAssignEnabled reports whether admins turned on automatic assignment
+func autoAssignEnabled(db *gorm.DB) bool {
+	var settings models.Settings
+	if err := db.First(&settings).Error; err != nil {
+		return false
+	}
+	return settings.AutoAssign
+}
+
+// previouslyOfferedTranslators lists translators who already turned a document down or let it expire,
+// they are left out when the document is matched again
+func previouslyOfferedTranslators(db *gorm.DB, documentID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.AssignmentAttempt{}).
+		Where("document_id = ? AND outcome IN ?", documentID, []string{models.AssignmentDeclined, models.AssignmentExpired}).
+		Distinct().
+		Pluck("translator_id", &ids).Error
+	return ids, err
+}
+
+// assignTranslator offers a paid document to a translator and records the attempt. Both are
+// saved together, on failure the document is left as it was.
+func assignTranslator(db *gorm.DB, document *models.Document, translatorID uint, by actor, reason string) error {
+	original := *document
+	document.TranslatorID = translatorID
+	document.AssignmentTime = time.Now() // Set the assignment time
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := changeDocumentState(tx, document, models.StateAssigned, by, models.EventAssigned, reason); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.AssignmentAttempt{
+			DocumentID:   document.ID,
+			TranslatorID: translatorID,
+			AssignedByID: by.ID,
+			Outcome:      models.AssignmentPending,
+		}).Error
+	})
+	if err != nil {
+		*document = original
+	}
+	return err
+}
+
+// closeAssignmentAttempt records how the pending offer of a document to a translator ended
+func closeAssignmentAttempt(db *gorm.DB, documentID uint, translatorID uint, outcome string) error {
+	now := time.Now()
+	return db.Model(&models.AssignmentAttempt{}).
+		Where("document_id = ? AND translator_id = ? AND outcome = ?", documentID, translatorID, models.AssignmentPending).
+		Updates(map[string]interface{}{"outcome": outcome, "responded_at": &now}).Error
+}
+
+// releaseAssignment puts a document the translator declined or let expire back in the
+// unassigned pool, tells the admins and offers it to the next translator when auto-assign is on
+func releaseAssignment(db *gorm.DB, document *models.Document, by actor, action string, outcome string, reasonCode string, reason string) error {
+	translatorID := document.TranslatorID
+	if err := changeDocumentStateWithReason(db, document, models.StatePaid, by, action, reasonCode, reason); err != nil {
+		return err
+	}
+
+	if err := closeAssignmentAttempt(db, document.ID, translatorID, outcome); err != nil {
+		log.Printf("Failed to close assignment attempt for document ID %d: %v", document.ID, err)
+	}
+
+	if err := autoAssignDocument(db, document); err != nil {
+		log.Printf("Failed to auto-assign document ID %d: %v", document.ID, err)
+	}
+
+	message := "A translator has refused to translate a document." + reasonText(action, reasonCode, reason)
+	if outcome == models.AssignmentExpired {
+		message = "A translator did not respond to an assignment in time."
+	}
+	if document.State == models.StatePaid {
+		message += " The document is waiting to be reassigned."
+	} else {
+		message += " The document has been offered to another translator."
+	}
+	if err := notifyAdmins(document, message, db); err != nil {
+		log.Printf("Failed to notify admins about document ID %d: %v", document.ID, err)
+	}
+
+	return nil
+}
+
+// autoAssignDocument gives a paid document to the best translator who hasn't
+// already turned it down. It does nothing when automatic assignment is off.
+func autoAssignDocument(db *gorm.DB, document *models.Document) error {
+	if !autoAssignEnabled(db) {
+		return nil
+	}
+
+	exclude, err := previouslyOfferedTranslators(db, document.ID)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := findTranslators(db, document.SourceLanguage, document.TargetLanguage, document.Category, exclude)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return errNoTranslatorAvailable
+	}
+
+	translator := candidates[0]
+	if err := assignTranslator(db, document, translator.ID, systemActor, "Automatically assigned"); err != nil {
+		return err
+	}
+
+	message := "A document has been assigned to you."
+	if err := CreateTypedNotification(models.NotificationDocumentAssigned, translator.ID, document.ID, message, db); err != nil {
+		log.Printf("Failed to notify translator ID %d: %v", translator.ID, err)
+	}
+
+	log.Printf("Document ID %d automatically assigned to translator ID %d", document.ID, translator.ID)
+	return nil
+}
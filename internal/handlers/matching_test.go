@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// rate stores a rating of stars for translator
+func rate(t *testing.T, db *gorm.DB, translator models.User, stars int) {
+	t.Helper()
+	if err := db.Create(&models.Rating{UserID: translator.ID + 1000, TranslatorID: translator.ID, DocumentID: 1, Rating: stars}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// enableAutoAssign turns automatic assignment on
+func enableAutoAssign(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	var settings models.Settings
+	if err := db.FirstOrCreate(&settings).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&settings).Update("auto_assign", true).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// names lists the translators of ours among found, in the order they were found
+func names(found []TranslatorWithRating, ours map[uint]string) []string {
+	var listed []string
+	for _, translator := range found {
+		if name, ok := ours[translator.ID]; ok {
+			listed = append(listed, name)
+		}
+	}
+	return listed
+}
+
+func TestFindTranslatorsRanking(t *testing.T) {
+	db := databasetest.Open(t)
+	owner := createTestUser(t, db, "owner", models.RoleUser)
+
+	translators := map[string]models.User{}
+	for _, name := range []string{"top", "busy", "good", "unrated", "unvetted", "french", "away", "full", "paused"} {
+		translators[name] = createTestUser(t, db, name, models.RoleTranslator)
+	}
+	rate(t, db, translators["top"], 5)
+	rate(t, db, translators["busy"], 5)
+	rate(t, db, translators["good"], 3)
+	rate(t, db, translators["unvetted"], 5)
+
+	busy := createTestDocument(t, db, owner, models.StateTranslating)
+	db.Model(&busy).Update("translator_id", translators["busy"].ID)
+	db.Model(&models.User{}).Where("id = ?", translators["unvetted"].ID).Update("vetting_status", models.VettingPendingReview)
+	db.Model(&models.User{}).Where("id = ?", translators["french"].ID).Update("proficient_languages", "{en,fr}")
+	db.Create(&models.TimeOff{UserID: translators["away"].ID, StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)})
+	db.Model(&models.User{}).Where("id = ?", translators["full"].ID).Update("max_concurrent_jobs", 0)
+	db.Model(&models.User{}).Where("id = ?", translators["paused"].ID).Update("available", false)
+
+	ours := map[uint]string{}
+	for name, translator := range translators {
+		ours[translator.ID] = name
+	}
+
+	found, err := findTranslators(db, "en", "id", models.CategoryGeneral, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Best rated first, the least busy among equals, translators without ratings last
+	want := []string{"top", "busy", "good", "unrated"}
+	if got := names(found, ours); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Fatalf("found %v, want %v", got, want)
+	}
+
+	found, err = findTranslators(db, "en", "id", models.CategoryGeneral, []uint{translators["top"].ID, translators["good"].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(found, ours); len(got) != 2 || got[0] != "busy" || got[1] != "unrated" {
+		t.Fatalf("found %v after excluding top and good", got)
+	}
+}
+
+func TestAutoAssignSkipsTranslatorsWhoTurnedTheDocumentDown(t *testing.T) {
+	db := databasetest.Open(t)
+	enableAutoAssign(t, db)
+	declined := createTestUser(t, db, "declined", models.RoleTranslator)
+	rate(t, db, declined, 5)
+	next := createTestUser(t, db, "next", models.RoleTranslator)
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StatePaid)
+	db.Create(&models.AssignmentAttempt{DocumentID: document.ID, TranslatorID: declined.ID, Outcome: models.AssignmentDeclined})
+
+	if err := autoAssignDocument(db, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved models.Document
+	db.First(&saved, document.ID)
+	if saved.State != models.StateAssigned || saved.TranslatorID != next.ID {
+		t.Fatalf("document is %s with translator %d, want %d", saved.State, saved.TranslatorID, next.ID)
+	}
+	var pending int64
+	db.Model(&models.AssignmentAttempt{}).Where("document_id = ? AND translator_id = ? AND outcome = ?", document.ID, saved.TranslatorID, models.AssignmentPending).Count(&pending)
+	if pending != 1 {
+		t.Fatalf("%d pending attempts recorded for the new translator", pending)
+	}
+}
+
+func TestAutoAssignLeavesDocumentPaidWhenNobodyMatches(t *testing.T) {
+	db := databasetest.Open(t)
+	enableAutoAssign(t, db)
+	createTestUser(t, db, "translator", models.RoleTranslator)
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StatePaid)
+	document.TargetLanguage = "xx-nobody"
+	db.Model(&document).Update("target_language", document.TargetLanguage)
+
+	if err := autoAssignDocument(db, &document); !errors.Is(err, errNoTranslatorAvailable) {
+		t.Fatalf("autoAssignDocument returned %v, want errNoTranslatorAvailable", err)
+	}
+
+	var saved models.Document
+	db.First(&saved, document.ID)
+	if saved.State != models.StatePaid || saved.TranslatorID != 0 {
+		t.Fatalf("document is %s with translator %d", saved.State, saved.TranslatorID)
+	}
+}
+
+func TestAssignTranslatorKeepsDocumentWhenAttemptFails(t *testing.T) {
+	db := databasetest.Open(t)
+	translator := createTestUser(t, db, "translator", models.RoleTranslator)
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StatePaid)
+
+	// Dropped inside the test transaction, so the attempt can't be written
+	if err := db.Migrator().DropTable(&models.AssignmentAttempt{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignTranslator(db, &document, translator.ID, systemActor, ""); err == nil {
+		t.Fatal("assignTranslator succeeded without the attempts table")
+	}
+	if document.State != models.StatePaid || document.TranslatorID != 0 {
+		t.Fatalf("document in memory is %s with translator %d", document.State, document.TranslatorID)
+	}
+
+	var saved models.Document
+	db.First(&saved, document.ID)
+	if saved.State != models.StatePaid || saved.TranslatorID != 0 {
+		t.Fatalf("document was saved as %s with translator %d", saved.State, saved.TranslatorID)
+	}
+	var events int64
+	db.Model(&models.DocumentEvent{}).Where("document_id = ? AND action = ?", document.ID, models.EventAssigned).Count(&events)
+	if events != 0 {
+		t.Fatal("the assignment was recorded")
+	}
+}
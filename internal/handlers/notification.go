@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"translation-app-backend/internal/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -21,19 +22,82 @@ func FetchNotifications(db *gorm.DB) fiber.Handler {
 }
 
 func CreateNotification(userID uint, documentID uint, message string, db *gorm.DB) error {
-		notification := models.Notification{
-			UserID:  userID,
-			DocumentID: documentID,
-			Message: message,
-		}
+	return createNotifications(models.NotificationGeneral, []uint{userID}, documentID, message, db)
+}
 
-		if err := db.Create(&notification).Error; err != nil {
-			return err
-		}
+// CreateTypedNotification creates a notification of a specific type. Types with an
+// email template are also mailed to users who opted in to email notifications.
+func CreateTypedNotification(kind string, userID uint, documentID uint, message string, db *gorm.DB) error {
+	return createNotifications(kind, []uint{userID}, documentID, message, db)
+}
+
+// CreateNotifications sends the same notification to a set of users
+func CreateNotifications(userIDs []uint, documentID uint, message string, db *gorm.DB) error {
+	return createNotifications(models.NotificationGeneral, userIDs, documentID, message, db)
+}
 
+func createNotifications(kind string, userIDs []uint, documentID uint, message string, db *gorm.DB) error {
+	if len(userIDs) == 0 {
 		return nil
 	}
 
+	notifications := make([]models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, models.Notification{
+			UserID:     userID,
+			DocumentID: documentID,
+			Type:       kind,
+			Message:    message,
+		})
+	}
+
+	if err := db.Create(&notifications).Error; err != nil {
+		return err
+	}
+
+	publishNotifications(notifications)
+
+	// A missing email must never fail the action that caused the notification
+	if err := queueNotificationEmails(db, notifications); err != nil {
+		log.Printf("Failed to queue notification emails: %v", err)
+	}
+
+	return nil
+}
+
+// adminRecipients returns the admins who should hear about a document: those
+// subscribed to it or its category, plus every admin without subscriptions
+func adminRecipients(db *gorm.DB, document *models.Document) ([]uint, error) {
+	var adminIDs []uint
+	err := db.Model(&models.User{}).
+		Where("role = ?", models.RoleAdmin).
+		Where(`(NOT EXISTS (SELECT 1 FROM admin_subscriptions s WHERE s.user_id = users.id)
+			OR EXISTS (SELECT 1 FROM admin_subscriptions s WHERE s.user_id = users.id AND (s.document_id = ? OR s.category = ?)))`,
+			document.ID, document.Category).
+		Pluck("id", &adminIDs).Error
+	return adminIDs, err
+}
+
+// notifyAdmins sends a notification about a document to the admins following it
+func notifyAdmins(document *models.Document, message string, db *gorm.DB) error {
+	adminIDs, err := adminRecipients(db, document)
+	if err != nil {
+		return err
+	}
+
+	return CreateNotifications(adminIDs, document.ID, message, db)
+}
+
+// notifyAllAdmins sends a notification that isn't about a document to every admin
+func notifyAllAdmins(message string, db *gorm.DB) error {
+	var adminIDs []uint
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Pluck("id", &adminIDs).Error; err != nil {
+		return err
+	}
+
+	return CreateNotifications(adminIDs, 0, message, db)
+}
+
 func MarkNotificationsAsRead(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Locals("userID")
@@ -45,3 +109,70 @@ func MarkNotificationsAsRead(db *gorm.DB) fiber.Handler {
 		return c.JSON(fiber.Map{"message": "All notifications marked as read"})
 	}
 }
+
+// GetAdminSubscriptions lists the subscriptions of the current admin
+func GetAdminSubscriptions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID")
+
+		var subscriptions []models.AdminSubscription
+		if err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&subscriptions).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch subscriptions"})
+		}
+
+		return c.JSON(subscriptions)
+	}
+}
+
+// CreateAdminSubscription subscribes the current admin to a document or a category
+func CreateAdminSubscription(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var input struct {
+			DocumentID uint   `json:"document_id"`
+			Category   string `json:"category"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		subscription := models.AdminSubscription{
+			UserID:     uint(userID),
+			DocumentID: input.DocumentID,
+			Category:   input.Category,
+		}
+		if err := subscription.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if subscription.DocumentID != 0 {
+			if err := db.First(&models.Document{}, subscription.DocumentID).Error; err != nil {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+			}
+		}
+
+		if err := db.Create(&subscription).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create subscription"})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(subscription)
+	}
+}
+
+// DeleteAdminSubscription removes one of the current admin's subscriptions
+func DeleteAdminSubscription(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID")
+
+		result := db.Where("id = ? AND user_id = ?", c.Params("id"), userID).Delete(&models.AdminSubscription{})
+		if result.Error != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete subscription"})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Subscription not found"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Subscription deleted successfully"})
+	}
+}
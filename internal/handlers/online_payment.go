@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/payments"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// paymentConfirmed tells the customer their payment went through, issues the receipt and
+// offers the now paid document to a translator. The document must already be in StatePaid.
+func paymentConfirmed(db *gorm.DB, document *models.Document, message string) {
+	if err := CreateTypedNotification(models.NotificationPaymentApproved, document.UserID, document.ID, message, db); err != nil {
+		log.Printf("Failed to notify user ID %d: %v", document.UserID, err)
+	}
+
+	if _, err := issueInvoice(db, document, models.InvoiceKindReceipt); err != nil {
+		log.Printf("Failed to issue receipt for document ID %d: %v", document.ID, err)
+	}
+
+	// Paid documents are ready for a translator, admins assign manually when this finds nobody
+	if err := autoAssignDocument(db, document); err != nil {
+		log.Printf("Failed to auto-assign document ID %d: %v", document.ID, err)
+	}
+}
+
+// newPaymentReference returns a unique reference for a payment of a document
+func newPaymentReference(documentID uint) (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("DOC%d-%s", documentID, hex.EncodeToString(buf)), nil
+}
+
+// CreatePaymentIntent starts an online payment of the accepted quote and returns the page
+// the customer pays on. Uploading a receipt stays available when the gateway is down.
+func CreatePaymentIntent(db *gorm.DB, provider payments.Provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+		document := c.Locals("document").(*models.Document)
+
+		if provider == nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Online payment is not available, please upload a payment receipt"})
+		}
+		if document.UserID != by.ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can pay for it"})
+		}
+		if document.State != models.StateQuoted {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Only documents with an accepted quote can be paid"})
+		}
+
+		// Asking twice returns the payment that is still open instead of charging again
+		var open models.PaymentIntent
+		err := db.Where("document_id = ? AND status = ?", document.ID, models.PaymentPending).Order("created_at desc").First(&open).Error
+		if err == nil && open.Reusable(document.Quote.Amount, time.Now()) {
+			return c.JSON(fiber.Map{"payment_url": open.PaymentURL, "payment": open})
+		}
+
+		var user models.User
+		if err := db.First(&user, document.UserID).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		reference, err := newPaymentReference(document.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start payment"})
+		}
+
+		currency := payments.Currency()
+		intent, err := provider.CreateIntent(c.UserContext(), payments.IntentRequest{
+			Reference:     reference,
+			Amount:        document.Quote.Amount,
+			Currency:      currency,
+			Description:   "Translation of " + document.Title,
+			CustomerName:  user.Username,
+			CustomerEmail: user.Email,
+		})
+		if err != nil {
+			log.Printf("Failed to create payment intent for document ID %d: %v", document.ID, err)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "The payment provider is unavailable, please try again or upload a payment receipt"})
+		}
+
+		payment := models.PaymentIntent{
+			DocumentID: document.ID,
+			UserID:     document.UserID,
+			Provider:   provider.Name(),
+			ProviderID: intent.ProviderID,
+			Reference:  reference,
+			Amount:     document.Quote.Amount,
+			Currency:   currency,
+			Status:     models.PaymentPending,
+			PaymentURL: intent.PaymentURL,
+			ExpiresAt:  intent.ExpiresAt,
+		}
+		if err := db.Create(&payment).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save payment"})
+		}
+
+		return c.JSON(fiber.Map{"payment_url": payment.PaymentURL, "payment": payment})
+	}
+}
+
+// GetPaymentIntents lists the online payments started for a document
+func GetPaymentIntents(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if !canSeeInvoices(document, actorFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can see its payments"})
+		}
+
+		var intents []models.PaymentIntent
+		if err := db.Where("document_id = ?", document.ID).Order("created_at desc").Find(&intents).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch payments"})
+		}
+
+		return c.JSON(intents)
+	}
+}
+
+// PaymentWebhook receives the signed callbacks of the payment gateway and confirms the
+// payment of the document without an admin. Callbacks can repeat, handling is idempotent.
+func PaymentWebhook(db *gorm.DB, provider payments.Provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if provider == nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Online payment is not configured"})
+		}
+
+		event, err := provider.ParseWebhook(func(key string) string { return c.Get(key) }, c.Body())
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var payment models.PaymentIntent
+		if err := db.Where("reference = ? AND provider = ?", event.Reference, provider.Name()).First(&payment).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Payment not found"})
+		}
+		if payment.Status == models.PaymentPaid {
+			return c.JSON(fiber.Map{"message": "Payment already confirmed"})
+		}
+
+		switch event.Status {
+		case payments.StatusPaid:
+			return confirmOnlinePayment(c, db, &payment, event)
+		case payments.StatusFailed, payments.StatusExpired:
+			if err := db.Model(&payment).Where("status = ?", models.PaymentPending).Update("status", string(event.Status)).Error; err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update payment"})
+			}
+		}
+
+		return c.JSON(fiber.Map{"message": "Webhook processed"})
+	}
+}
+
+// confirmOnlinePayment marks a payment the gateway settled as paid and moves its document to StatePaid
+func confirmOnlinePayment(c *fiber.Ctx, db *gorm.DB, payment *models.PaymentIntent, event payments.Event) error {
+	var document models.Document
+	if err := db.First(&document, payment.DocumentID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+	}
+
+	if models.RoundMoney(event.Amount) < payment.Amount {
+		log.Printf("Payment %s of document ID %d settled %.2f instead of %.2f", payment.Reference, document.ID, event.Amount, payment.Amount)
+		message := "An online payment settled for less than the quoted price, please check it with the payment provider."
+		if err := notifyAdmins(&document, message, db); err != nil {
+			log.Printf("Failed to notify admins about document ID %d: %v", document.ID, err)
+		}
+		return c.JSON(fiber.Map{"message": "Amount does not match, left for an admin"})
+	}
+
+	// Money arrived for a document that was already paid by receipt or cancelled meanwhile
+	alreadySettled := document.State != models.StateQuoted
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(payment).Where("status <> ?", models.PaymentPaid).Updates(map[string]interface{}{
+			"status":      models.PaymentPaid,
+			"paid_at":     now,
+			"provider_id": event.ProviderID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || alreadySettled {
+			return nil
+		}
+
+		schedulePayment(tx, &document)
+		return changeDocumentState(tx, &document, models.StatePaid, systemActor, models.EventPaymentReceived, "Paid online, reference "+payment.Reference)
+	})
+	if err != nil {
+		// The gateway retries failed callbacks
+		log.Printf("Failed to confirm payment %s of document ID %d: %v", payment.Reference, document.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to confirm payment"})
+	}
+
+	if alreadySettled {
+		message := "An online payment was received for a document that is no longer waiting for payment, it may need a refund."
+		if err := notifyAdmins(&document, message, db); err != nil {
+			log.Printf("Failed to notify admins about document ID %d: %v", document.ID, err)
+		}
+		return c.JSON(fiber.Map{"message": "Payment recorded, document was not waiting for payment"})
+	}
+
+	if document.State == models.StatePaid {
+		paymentConfirmed(db, &document, "Your payment has been received.")
+	}
+	return c.JSON(fiber.Map{"message": "Payment confirmed"})
+}
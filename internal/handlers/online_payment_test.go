@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/payments"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const webhookSecret = "whsec_test"
+
+// webhookFixture is a quoted document with an open online payment of 100
+type webhookFixture struct {
+	db       *gorm.DB
+	app      *fiber.App
+	admin    models.User
+	document models.Document
+	payment  models.PaymentIntent
+}
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+	db := databasetest.Open(t)
+
+	stamp := time.Now().UnixNano()
+	owner := models.User{Username: "owner", Email: fmt.Sprintf("owner-%d@example.test", stamp), Role: models.RoleUser, Verified: true}
+	admin := models.User{Username: "admin", Email: fmt.Sprintf("admin-%d@example.test", stamp), Role: models.RoleAdmin, Verified: true}
+	for _, user := range []*models.User{&owner, &admin} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	document := models.Document{
+		UserID:         owner.ID,
+		State:          models.StateQuoted,
+		Title:          "Annual report",
+		Category:       models.CategoryGeneral,
+		SourceLanguage: "en",
+		TargetLanguage: "id",
+		Quote:          models.Quote{Amount: 100, QuotedAt: &now},
+	}
+	if err := db.Create(&document).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	payment := models.PaymentIntent{
+		DocumentID: document.ID,
+		UserID:     owner.ID,
+		Provider:   "fake",
+		Reference:  fmt.Sprintf("DOC%d-test", document.ID),
+		Amount:     100,
+		Currency:   payments.DefaultCurrency,
+		Status:     models.PaymentPending,
+	}
+	if err := db.Create(&payment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/webhook", PaymentWebhook(db, payments.NewFake(webhookSecret)))
+
+	return &webhookFixture{db: db, app: app, admin: admin, document: document, payment: payment}
+}
+
+// callback sends the gateway's signed callback for the fixture's payment
+func (f *webhookFixture) callback(t *testing.T, status payments.Status, amount float64) int {
+	t.Helper()
+	body, signature, err := payments.FakeWebhook(webhookSecret, payments.Event{
+		Reference:  f.payment.Reference,
+		ProviderID: "fake_1",
+		Status:     status,
+		Amount:     amount,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payments.SignatureHeader, signature)
+	resp, err := f.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (f *webhookFixture) reload(t *testing.T) (models.Document, models.PaymentIntent) {
+	t.Helper()
+	var document models.Document
+	var payment models.PaymentIntent
+	if err := f.db.First(&document, f.document.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := f.db.First(&payment, f.payment.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return document, payment
+}
+
+func (f *webhookFixture) count(t *testing.T, model interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := f.db.Model(model).Where(query, args...).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestPaymentWebhookRejectsUnsignedCallbacks(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	body := []byte(fmt.Sprintf(`{"id":"fake_1","reference":%q,"status":"paid","amount":100}`, f.payment.Reference))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set(payments.SignatureHeader, payments.Sign("another secret", body))
+	resp, err := f.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("forged callback returned %d, want 401", resp.StatusCode)
+	}
+
+	if document, _ := f.reload(t); document.State != models.StateQuoted {
+		t.Fatalf("forged callback moved the document to %s", document.State)
+	}
+}
+
+func TestPaymentWebhookPaidMovesQuotedDocumentToPaid(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	if status := f.callback(t, payments.StatusPaid, 100); status != http.StatusOK {
+		t.Fatalf("callback returned %d, want 200", status)
+	}
+
+	document, payment := f.reload(t)
+	if document.State != models.StatePaid {
+		t.Fatalf("document is %s, want %s", document.State, models.StatePaid)
+	}
+	if payment.Status != models.PaymentPaid || payment.PaidAt == nil || payment.ProviderID != "fake_1" {
+		t.Fatalf("payment not confirmed: %+v", payment)
+	}
+	if n := f.count(t, &models.DocumentEvent{}, "document_id = ? AND action = ?", document.ID, models.EventPaymentReceived); n != 1 {
+		t.Fatalf("recorded %d payment events, want 1", n)
+	}
+}
+
+func TestPaymentWebhookRepeatedCallbackIsNoOp(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	for i := 0; i < 2; i++ {
+		if status := f.callback(t, payments.StatusPaid, 100); status != http.StatusOK {
+			t.Fatalf("callback %d returned %d, want 200", i+1, status)
+		}
+	}
+
+	if document, _ := f.reload(t); document.State != models.StatePaid {
+		t.Fatalf("document is %s, want %s", document.State, models.StatePaid)
+	}
+	if n := f.count(t, &models.DocumentEvent{}, "document_id = ? AND action = ?", f.document.ID, models.EventPaymentReceived); n != 1 {
+		t.Fatalf("recorded %d payment events after a repeated callback, want 1", n)
+	}
+	if n := f.count(t, &models.Invoice{}, "document_id = ? AND kind = ?", f.document.ID, models.InvoiceKindReceipt); n > 1 {
+		t.Fatalf("issued %d receipts after a repeated callback", n)
+	}
+}
+
+func TestPaymentWebhookUnderpaymentIsLeftForAnAdmin(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	if status := f.callback(t, payments.StatusPaid, 60); status != http.StatusOK {
+		t.Fatalf("callback returned %d, want 200", status)
+	}
+
+	document, payment := f.reload(t)
+	if document.State != models.StateQuoted || payment.Status != models.PaymentPending {
+		t.Fatalf("underpayment settled the document: document %s, payment %s", document.State, payment.Status)
+	}
+	if n := f.count(t, &models.Notification{}, "user_id = ? AND document_id = ?", f.admin.ID, document.ID); n == 0 {
+		t.Fatal("admins were not told about the underpayment")
+	}
+}
+
+func TestPaymentWebhookForSettledDocumentNotifiesAdmins(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	// Paid by receipt while the online payment was still open
+	if err := f.db.Model(&models.Document{}).Where("id = ?", f.document.ID).Update("state", models.StatePaid).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if status := f.callback(t, payments.StatusPaid, 100); status != http.StatusOK {
+		t.Fatalf("callback returned %d, want 200", status)
+	}
+
+	_, payment := f.reload(t)
+	if payment.Status != models.PaymentPaid {
+		t.Fatalf("payment is %s, want it recorded as %s", payment.Status, models.PaymentPaid)
+	}
+	if n := f.count(t, &models.DocumentEvent{}, "document_id = ? AND action = ?", f.document.ID, models.EventPaymentReceived); n != 0 {
+		t.Fatalf("recorded %d payment events for an already paid document", n)
+	}
+	if n := f.count(t, &models.Notification{}, "user_id = ? AND document_id = ?", f.admin.ID, f.document.ID); n == 0 {
+		t.Fatal("admins were not told about the payment of a settled document")
+	}
+}
@@ -1,21 +1,23 @@
 package handlers
 
 import (
-	"io"
+	"log"
 	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-func UploadPaymentReceipt(db *gorm.DB) fiber.Handler {
+func UploadPaymentReceipt(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userID := c.Locals("userID")
-		documentID := c.Params("id")
+		document := c.Locals("document").(*models.Document)
+		if document.UserID != actorFrom(c).ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can upload its payment receipt"})
+		}
 
-		var document models.Document
-		if err := db.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		if document.State != models.StateQuoted {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Payment receipts can only be uploaded for a quoted document"})
 		}
 
 		// Parse the form
@@ -32,28 +34,39 @@ func UploadPaymentReceipt(db *gorm.DB) fiber.Handler {
 
 		file := files[0]
 
-		// Read the file content
-		fileContent, err := file.Open()
+		// Store the receipt in the blob store, the document only keeps its key
+		key, err := saveUpload(c, store, "receipts", file)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open file: " + err.Error()})
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to store file: " + err.Error()})
 		}
-		defer fileContent.Close()
 
-		// Read the file data
-		fileData, err := io.ReadAll(fileContent)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read file: " + err.Error()})
+		// Only the receipt columns are written, and only while the document still waits for
+		// payment, so an approval or online payment that landed meanwhile isn't overwritten
+		previousKey := document.PaymentReceiptKey
+		result := db.Model(&models.Document{}).
+			Where("id = ? AND state = ?", document.ID, models.StateQuoted).
+			Updates(map[string]interface{}{
+				"payment_receipt_key":       key,
+				"payment_receipt_file_name": file.Filename,
+			})
+		if result.Error != nil {
+			deleteBlob(c, store, key)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
 		}
-
-		// Store file content in the database
-		document.PaymentReceiptContent = fileData
+		if result.RowsAffected == 0 {
+			deleteBlob(c, store, key)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Document is no longer waiting for payment"})
+		}
+		document.PaymentReceiptKey = key
 		document.PaymentReceiptFileName = file.Filename
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		deleteBlob(c, store, previousKey)
+
+		if err := recordDocumentEvent(db, document, actorFrom(c), models.EventReceiptUploaded, ""); err != nil {
+			log.Printf("Failed to record event for document ID %d: %v", document.ID, err)
 		}
 
 		message := "User has uploaded the payment receipt."
-		if err := CreateNotification(2, document.ID, message, db); err != nil {
+		if err := notifyAdmins(document, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
 
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestUploadPaymentReceiptDoesNotUndoConfirmedPayment(t *testing.T) {
+	db := databasetest.Open(t)
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := createTestUser(t, db, "owner", models.RoleUser)
+	document := createTestDocument(t, db, owner, models.StateQuoted)
+
+	// DocumentAccess loaded the document while it was still quoted, an admin approves the payment right after
+	stale := document
+	if err := db.Model(&models.Document{}).Where("id = ?", document.ID).
+		Updates(map[string]interface{}{"state": models.StatePaid, "payment_confirmed": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/documents/:id/upload-receipt", as(owner), func(c *fiber.Ctx) error {
+		c.Locals("document", &stale)
+		return c.Next()
+	}, UploadPaymentReceipt(db, store))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("receipt", "transfer.pdf")
+	part.Write([]byte("%PDF-1.4 receipt"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/documents/%d/upload-receipt", document.ID), &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("upload after the payment was confirmed returned %d, want 409", resp.StatusCode)
+	}
+
+	var saved models.Document
+	db.First(&saved, document.ID)
+	if saved.State != models.StatePaid || !saved.PaymentConfirmed || saved.PaymentReceiptKey != "" {
+		t.Fatalf("upload changed the paid document: state %s, confirmed %v, receipt %q", saved.State, saved.PaymentConfirmed, saved.PaymentReceiptKey)
+	}
+}
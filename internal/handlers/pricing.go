@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type pricingRuleInput struct {
+	SourceLanguage string  `json:"source_language"`
+	TargetLanguage string  `json:"target_language"`
+	Category       string  `json:"category"`
+	PricePerWord   float64 `json:"price_per_word"`
+	PricePerPage   float64 `json:"price_per_page"`
+	MinimumCharge  float64 `json:"minimum_charge"`
+	Active         *bool   `json:"active"`
+}
+
+func (input pricingRuleInput) apply(rule *models.PricingRule) {
+	rule.SourceLanguage = input.SourceLanguage
+	rule.TargetLanguage = input.TargetLanguage
+	rule.Category = input.Category
+	rule.PricePerWord = input.PricePerWord
+	rule.PricePerPage = input.PricePerPage
+	rule.MinimumCharge = input.MinimumCharge
+	if input.Active != nil {
+		rule.Active = *input.Active
+	}
+}
+
+func GetPricingRules(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var rules []models.PricingRule
+		if err := db.Order("id asc").Find(&rules).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch pricing rules"})
+		}
+
+		return c.JSON(rules)
+	}
+}
+
+func CreatePricingRule(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input pricingRuleInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		rule := models.PricingRule{Active: true}
+		input.apply(&rule)
+		if err := rule.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Create(&rule).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create pricing rule"})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(rule)
+	}
+}
+
+func UpdatePricingRule(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var rule models.PricingRule
+		if err := db.First(&rule, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Pricing rule not found"})
+		}
+
+		var input pricingRuleInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		input.apply(&rule)
+		if err := rule.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Save(&rule).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update pricing rule"})
+		}
+
+		return c.JSON(rule)
+	}
+}
+
+func DeletePricingRule(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result := db.Delete(&models.PricingRule{}, c.Params("id"))
+		if result.Error != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete pricing rule"})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Pricing rule not found"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Pricing rule deleted successfully"})
+	}
+}
+
+type urgencyTierInput struct {
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Multiplier     float64 `json:"multiplier"`
+	TurnaroundDays int     `json:"turnaround_days"`
+}
+
+func (input urgencyTierInput) apply(tier *models.UrgencyTier) {
+	tier.Code = input.Code
+	tier.Name = input.Name
+	tier.Multiplier = input.Multiplier
+	tier.TurnaroundDays = input.TurnaroundDays
+}
+
+func GetUrgencyTiers(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var tiers []models.UrgencyTier
+		if err := db.Order("multiplier asc").Find(&tiers).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch urgency tiers"})
+		}
+
+		return c.JSON(tiers)
+	}
+}
+
+func CreateUrgencyTier(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input urgencyTierInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		var tier models.UrgencyTier
+		input.apply(&tier)
+		if err := tier.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var exists int64
+		db.Model(&models.UrgencyTier{}).Where("code = ?", tier.Code).Count(&exists)
+		if exists > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Urgency code already in use"})
+		}
+
+		if err := db.Create(&tier).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create urgency tier"})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(tier)
+	}
+}
+
+func UpdateUrgencyTier(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var tier models.UrgencyTier
+		if err := db.First(&tier, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Urgency tier not found"})
+		}
+
+		var input urgencyTierInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		// Documents reference tiers by code, so the code of an existing tier is fixed
+		input.Code = tier.Code
+		input.apply(&tier)
+		if err := tier.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Save(&tier).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update urgency tier"})
+		}
+
+		return c.JSON(tier)
+	}
+}
+
+func DeleteUrgencyTier(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var tier models.UrgencyTier
+		if err := db.First(&tier, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Urgency tier not found"})
+		}
+
+		if tier.Code == models.UrgencyStandard {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "The standard urgency tier cannot be deleted"})
+		}
+
+		// Hard delete so the code can be reused, quoted documents keep their own multiplier
+		if err := db.Unscoped().Delete(&tier).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete urgency tier"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Urgency tier deleted successfully"})
+	}
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var errEmailTaken = errors.New("email already in use")
+
+// emailTaken reports whether another account already uses an email address
+func emailTaken(db *gorm.DB, email string, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.User{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// applyAccountChanges updates the username and email of a user. A new email has to be
+// verified again, the returned flag tells the caller to send the verification link.
+func applyAccountChanges(db *gorm.DB, user *models.User, username, email *string) (bool, error) {
+	if username != nil {
+		user.Username = strings.TrimSpace(*username)
+	}
+
+	emailChanged := false
+	if email != nil && !strings.EqualFold(strings.TrimSpace(*email), user.Email) {
+		newEmail := strings.TrimSpace(*email)
+		taken, err := emailTaken(db, newEmail, user.ID)
+		if err != nil {
+			return false, err
+		}
+		if taken {
+			return false, errEmailTaken
+		}
+
+		user.Email = newEmail
+		user.Verified = false
+		emailChanged = true
+	}
+
+	return emailChanged, nil
+}
+
+// accountChangeError responds with 409 for a taken email and 500 for anything else
+func accountChangeError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errEmailTaken) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check email"})
+}
+
+// GetMe returns the profile of the logged in user
+func GetMe(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		return c.JSON(user)
+	}
+}
+
+// UpdateMe lets a user change their username and email
+func UpdateMe(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var input struct {
+			Username *string `json:"username"`
+			Email    *string `json:"email"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		emailChanged, err := applyAccountChanges(db, &user, input.Username, input.Email)
+		if err != nil {
+			return accountChangeError(c, err)
+		}
+
+		if err := user.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Save(&user).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
+		}
+
+		if emailChanged {
+			if err := sendVerificationEmail(db, user); err != nil {
+				log.Printf("Failed to send verification email to user ID %d: %v", user.ID, err)
+			}
+		}
+
+		return c.JSON(user)
+	}
+}
+
+// ChangePassword sets a new password after checking the current one, other sessions are signed out
+func ChangePassword(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+		sessionID, _ := c.Locals("sessionID").(float64)
+
+		var input struct {
+			CurrentPassword string `json:"current_password"`
+			NewPassword     string `json:"new_password"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.CurrentPassword == "" || input.NewPassword == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "please provide current and new password"})
+		}
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "incorrect password"})
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), 12)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not hash password"})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.Session{}).
+				Where("user_id = ? AND id <> ? AND revoked_at IS NULL", user.ID, uint(sessionID)).
+				Update("revoked_at", time.Now()).Error
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to change password"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Password changed successfully"})
+	}
+}
+
+// addsEntries reports whether next holds a language or category that current doesn't
+func addsEntries(current, next []string) bool {
+	for _, entry := range next {
+		found := false
+		for _, existing := range current {
+			if strings.EqualFold(strings.TrimSpace(entry), strings.TrimSpace(existing)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTranslatorSkills sets the languages and categories a translator gave, nil keeps the current
+// ones. Vetting only checked what the translator had, adding to it sends an approved translator
+// back to review so they aren't matched on it unchecked. It reports whether that happened.
+func applyTranslatorSkills(user *models.User, languages, categories []string) bool {
+	adds := false
+	if languages != nil {
+		adds = adds || addsEntries(user.ProficientLanguages, languages)
+		user.ProficientLanguages = languages
+	}
+	if categories != nil {
+		adds = adds || addsEntries(user.Categories, categories)
+		user.Categories = categories
+	}
+
+	if !adds || user.VettingStatus != models.VettingApproved {
+		return false
+	}
+	user.VettingStatus = models.VettingPendingReview
+	return true
+}
+
+// requestReview tells admins a translator sent back to review by applyTranslatorSkills is waiting for them
+func requestReview(db *gorm.DB, user *models.User) {
+	message := "A vetted translator added languages or categories, their application needs another review."
+	if err := notifyAllAdmins(message, db); err != nil {
+		log.Printf("Failed to notify admins about translator ID %d: %v", user.ID, err)
+	}
+}
+
+// UpdateTranslatorProfile lets a translator edit their languages, categories, bio and availability
+func UpdateTranslatorProfile(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var input struct {
+			ProficientLanguages []string `json:"proficient_languages"`
+			Categories          []string `json:"categories"`
+			Bio                 *string  `json:"bio"`
+			Available           *bool    `json:"available"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+
+		var user models.User
+		if err := db.Where("id = ? AND role = ?", uint(userID), models.RoleTranslator).First(&user).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translator not found"})
+		}
+
+		backToReview := applyTranslatorSkills(&user, input.ProficientLanguages, input.Categories)
+		if input.Bio != nil {
+			user.Bio = *input.Bio
+		}
+		if input.Available != nil {
+			user.Available = *input.Available
+		}
+
+		if err := user.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Save(&user).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
+		}
+
+		if backToReview {
+			requestReview(db, &user)
+		}
+
+		return c.JSON(user)
+	}
+}
+
+// UpdateUser lets admins edit any account. Changing the role signs the user out.
+func UpdateUser(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Username            *string  `json:"username"`
+			Email               *string  `json:"email"`
+			Role                *string  `json:"role"`
+			ProficientLanguages []string `json:"proficient_languages"`
+			Categories          []string `json:"categories"`
+			Bio                 *string  `json:"bio"`
+			Available           *bool    `json:"available"`
+			Verified            *bool    `json:"verified"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+
+		var user models.User
+		if err := db.First(&user, c.Params("id")).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		emailChanged, err := applyAccountChanges(db, &user, input.Username, input.Email)
+		if err != nil {
+			return accountChangeError(c, err)
+		}
+
+		roleChanged := input.Role != nil && *input.Role != user.Role
+		if roleChanged {
+			user.Role = *input.Role
+			if user.Role == models.RoleTranslator && user.VettingStatus == "" {
+				user.VettingStatus = models.VettingPendingReview
+			}
+		}
+		if input.ProficientLanguages != nil {
+			user.ProficientLanguages = input.ProficientLanguages
+		}
+		if input.Categories != nil {
+			user.Categories = input.Categories
+		}
+		if input.Bio != nil {
+			user.Bio = *input.Bio
+		}
+		if input.Available != nil {
+			user.Available = *input.Available
+		}
+		// An admin can vouch for an address, this also overrides the reset done by an email change
+		if input.Verified != nil {
+			user.Verified = *input.Verified
+		}
+
+		if err := user.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := db.Save(&user).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user"})
+		}
+
+		if roleChanged {
+			if err := revokeUserSessions(db, user.ID); err != nil {
+				log.Printf("Failed to revoke sessions of user ID %d: %v", user.ID, err)
+			}
+		}
+		if emailChanged && !user.Verified {
+			if err := sendVerificationEmail(db, user); err != nil {
+				log.Printf("Failed to send verification email to user ID %d: %v", user.ID, err)
+			}
+		}
+
+		return c.JSON(user)
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestApplyTranslatorSkills(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		languages  []string
+		categories []string
+		review     bool
+	}{
+		{"unchanged", models.VettingApproved, []string{"en", "id"}, nil, false},
+		{"dropping a language", models.VettingApproved, []string{"en"}, nil, false},
+		{"same language in another case", models.VettingApproved, []string{"EN", "id"}, nil, false},
+		{"adding a language", models.VettingApproved, []string{"en", "id", "ja"}, nil, true},
+		{"adding a category", models.VettingApproved, nil, []string{models.CategoryGeneral, models.CategoryEngineering}, true},
+		{"adding before vetting", models.VettingPendingReview, []string{"en", "id", "ja"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{
+				Role:                models.RoleTranslator,
+				VettingStatus:       tt.status,
+				ProficientLanguages: []string{"en", "id"},
+				Categories:          []string{models.CategoryGeneral},
+			}
+			if got := applyTranslatorSkills(&user, tt.languages, tt.categories); got != tt.review {
+				t.Fatalf("applyTranslatorSkills = %v, want %v", got, tt.review)
+			}
+			want := tt.status
+			if tt.review {
+				want = models.VettingPendingReview
+			}
+			if user.VettingStatus != want {
+				t.Fatalf("vetting status %q, want %q", user.VettingStatus, want)
+			}
+			if tt.languages != nil && len(user.ProficientLanguages) != len(tt.languages) {
+				t.Fatalf("languages %v, want %v", user.ProficientLanguages, tt.languages)
+			}
+		})
+	}
+}
+
+func TestUpdateTranslatorProfileSendsNewLanguagesToReview(t *testing.T) {
+	db := databasetest.Open(t)
+	translator := createTestUser(t, db, "translator", models.RoleTranslator)
+	createTestUser(t, db, "admin", models.RoleAdmin)
+
+	app := fiber.New()
+	app.Patch("/profile", as(translator), UpdateTranslatorProfile(db))
+
+	if status, body := send(t, app, http.MethodPatch, "/profile", `{"bio":"Sworn translator"}`); status != http.StatusOK || body["VettingStatus"] != models.VettingApproved {
+		t.Fatalf("editing the bio returned %d: %v", status, body)
+	}
+	if status, body := send(t, app, http.MethodPatch, "/profile", `{"proficient_languages":["en","id","ja"]}`); status != http.StatusOK || body["VettingStatus"] != models.VettingPendingReview {
+		t.Fatalf("adding a language returned %d: %v", status, body)
+	}
+
+	var notifications int64
+	db.Model(&models.Notification{}).Where("document_id = 0").Count(&notifications)
+	if notifications == 0 {
+		t.Fatal("admins weren't asked to review the translator again")
+	}
+}
+
+func TestUpdateMe(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	other := createTestUser(t, db, "budi", models.RoleUser)
+
+	app := fiber.New()
+	app.Patch("/me", as(user), UpdateMe(db))
+
+	if status, body := send(t, app, http.MethodPatch, "/me", `{"username":"  Ayu Lestari "}`); status != http.StatusOK || body["Username"] != "Ayu Lestari" || body["Verified"] != true {
+		t.Fatalf("renaming returned %d: %v", status, body)
+	}
+	if status, _ := send(t, app, http.MethodPatch, "/me", fmt.Sprintf(`{"email":%q}`, other.Email)); status != http.StatusConflict {
+		t.Fatalf("taking another user's email returned %d, want 409", status)
+	}
+	if status, _ := send(t, app, http.MethodPatch, "/me", `{"username":" "}`); status != http.StatusBadRequest {
+		t.Fatalf("a blank username returned %d, want 400", status)
+	}
+
+	status, body := send(t, app, http.MethodPatch, "/me", `{"email":"ayu.new@example.test"}`)
+	if status != http.StatusOK || body["Email"] != "ayu.new@example.test" || body["Verified"] != false {
+		t.Fatalf("changing the email returned %d: %v", status, body)
+	}
+	var queued int64
+	db.Model(&models.EmailOutbox{}).Where("\"to\" = ?", "ayu.new@example.test").Count(&queued)
+	if queued != 1 {
+		t.Fatalf("queued %d verification emails to the new address, want 1", queued)
+	}
+}
+
+func TestChangePasswordSignsOutOtherSessions(t *testing.T) {
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old password"), bcrypt.MinCost)
+	db.Model(&user).Update("password", string(hash))
+	current := createTestSession(t, db, user)
+	createTestSession(t, db, user)
+
+	app := fiber.New()
+	app.Put("/me/password", as(user), func(c *fiber.Ctx) error {
+		c.Locals("sessionID", float64(current.ID))
+		return c.Next()
+	}, ChangePassword(db))
+
+	if status, _ := send(t, app, http.MethodPut, "/me/password", `{"current_password":"wrong","new_password":"new password"}`); status != http.StatusUnauthorized {
+		t.Fatalf("a wrong current password returned %d, want 401", status)
+	}
+	if n := activeSessions(t, db, user); n != 2 {
+		t.Fatalf("a refused change left %d active sessions, want 2", n)
+	}
+
+	if status, _ := send(t, app, http.MethodPut, "/me/password", `{"current_password":"old password","new_password":"new password"}`); status != http.StatusOK {
+		t.Fatalf("changing the password returned %d", status)
+	}
+
+	var sessions []models.Session
+	db.Where("user_id = ? AND revoked_at IS NULL", user.ID).Find(&sessions)
+	if len(sessions) != 1 || sessions[0].ID != current.ID {
+		t.Fatalf("active sessions after the change %+v, want only the current one", sessions)
+	}
+	db.First(&user, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new password")) != nil {
+		t.Fatal("the new password wasn't stored")
+	}
+}
+
+func TestUpdateUserRoleChangeRevokesSessions(t *testing.T) {
+	db := databasetest.Open(t)
+	admin := createTestUser(t, db, "admin", models.RoleAdmin)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	createTestSession(t, db, user)
+	createTestSession(t, db, user)
+
+	app := fiber.New()
+	app.Patch("/users/:id", as(admin), UpdateUser(db))
+	path := fmt.Sprintf("/users/%d", user.ID)
+
+	if status, _ := send(t, app, http.MethodPatch, path, `{"bio":"Prefers email"}`); status != http.StatusOK {
+		t.Fatalf("editing the bio returned %d", status)
+	}
+	if n := activeSessions(t, db, user); n != 2 {
+		t.Fatalf("editing without a role change left %d active sessions, want 2", n)
+	}
+
+	if status, _ := send(t, app, http.MethodPatch, path, `{"role":"superuser"}`); status != http.StatusBadRequest {
+		t.Fatalf("an unknown role returned %d, want 400", status)
+	}
+	if n := activeSessions(t, db, user); n != 2 {
+		t.Fatalf("a refused role change left %d active sessions, want 2", n)
+	}
+
+	status, body := send(t, app, http.MethodPatch, path, `{"role":"translator"}`)
+	if status != http.StatusOK || body["Role"] != models.RoleTranslator || body["VettingStatus"] != models.VettingPendingReview {
+		t.Fatalf("making the user a translator returned %d: %v", status, body)
+	}
+	if n := activeSessions(t, db, user); n != 0 {
+		t.Fatalf("a role change left %d active sessions, want 0", n)
+	}
+}
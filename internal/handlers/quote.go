@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	errNoPrice        = errors.New("no price applies to this document")
+	errUnknownUrgency = errors.New("unknown urgency")
+)
+
+// urgencyMultiplier looks up the price multiplier of an urgency tier, an empty code means standard
+func urgencyMultiplier(db *gorm.DB, code string) (string, float64, error) {
+	if code == "" {
+		code = models.UrgencyStandard
+	}
+
+	var tier models.UrgencyTier
+	if err := db.Where("code = ?", code).First(&tier).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", 0, err
+		}
+		if code == models.UrgencyStandard {
+			return code, 1, nil
+		}
+		return "", 0, errUnknownUrgency
+	}
+
+	return tier.Code, tier.Multiplier, nil
+}
+
+// quoteDocument prices a document with the most specific active pricing rule that has a
+// price for it, falling back to the global price per word from settings
+func quoteDocument(db *gorm.DB, document *models.Document) (models.Quote, error) {
+	urgency, multiplier, err := urgencyMultiplier(db, document.Urgency)
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	var rules []models.PricingRule
+	if err := db.Where("active = ?", true).Order("id desc").Find(&rules).Error; err != nil {
+		return models.Quote{}, err
+	}
+
+	// A specific rule with only a price per word can't price a document whose words
+	// couldn't be counted, a less specific rule with a price per page still can
+	var candidates []models.PricingRule
+	for _, rule := range rules {
+		if rule.Specificity(document) >= 0 {
+			candidates = append(candidates, rule)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Specificity(document) > candidates[j].Specificity(document)
+	})
+
+	var settings models.Settings
+	err = db.First(&settings).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Quote{}, err
+	}
+	if err == nil {
+		candidates = append(candidates, models.GlobalPricingRule(&settings))
+	}
+
+	for _, rule := range candidates {
+		if quote, err := models.NewQuote(document, rule, urgency, multiplier); err == nil {
+			quote.TaxRate = settings.TaxRate
+			return quote, nil
+		}
+	}
+	return models.Quote{}, errNoPrice
+}
+
+// errQuoteLocked is returned by saveQuote when the quote was accepted in the meantime
+var errQuoteLocked = errors.New("quote has already been accepted")
+
+// quoteUpdates are the columns saveQuote writes, the word count and every column of the quote
+func quoteUpdates(document *models.Document) map[string]interface{} {
+	quote := document.Quote
+	return map[string]interface{}{
+		"word_count":           document.WordCount,
+		"quote_rule_id":        quote.RuleID,
+		"quote_urgency":        quote.Urgency,
+		"quote_price_per_word": quote.PricePerWord,
+		"quote_price_per_page": quote.PricePerPage,
+		"quote_multiplier":     quote.Multiplier,
+		"quote_minimum_charge": quote.MinimumCharge,
+		"quote_amount":         quote.Amount,
+		"quote_manual":         quote.Manual,
+		"quote_quoted_at":      quote.QuotedAt,
+		"quote_discount":       quote.Discount,
+		"quote_coupon_code":    quote.CouponCode,
+		"quote_tax_rate":       quote.TaxRate,
+	}
+}
+
+// saveQuote writes a new quote of a document that hasn't been accepted yet. Only the quote
+// columns are written so a concurrent state change isn't overwritten.
+func saveQuote(tx *gorm.DB, document *models.Document) error {
+	result := tx.Model(&models.Document{}).
+		Where("id = ? AND state IN ?", document.ID, couponStates).
+		Updates(quoteUpdates(document))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errQuoteLocked
+	}
+	return nil
+}
+
+// GetDocumentQuote returns the price quote of one of the user's documents
+func GetDocumentQuote(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+		if document.UserID != actorFrom(c).ID && actorFrom(c).Role != models.RoleAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can see its quote"})
+		}
+
+		if document.Quote.QuotedAt == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No quote available for this document yet"})
+		}
+
+		return c.JSON(fiber.Map{"word_count": document.WordCount, "quote": document.Quote})
+	}
+}
+
+// RequoteDocument lets an admin correct the word count or set a manual price
+// for a document whose quote hasn't been accepted yet
+func RequoteDocument(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		documentID := c.Params("id")
+
+		var input struct {
+			WordCount *int     `json:"word_count"`
+			Amount    *float64 `json:"amount"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		var document models.Document
+		if err := db.Where("id = ?", documentID).First(&document).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		}
+
+		if document.State != models.StateSubmitted && document.State != models.StateApproved {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "The quote cannot change once it has been accepted"})
+		}
+
+		if input.WordCount != nil {
+			if *input.WordCount < 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Word count cannot be negative"})
+			}
+			document.WordCount = *input.WordCount
+		}
+
+		if input.Amount != nil {
+			if *input.Amount <= 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+			}
+			now := time.Now()
+			document.Quote = models.Quote{
+				Urgency:  document.Urgency,
+				Amount:   models.RoundMoney(*input.Amount),
+				Manual:   true,
+				QuotedAt: &now,
+				TaxRate:  taxRate(db),
+			}
+		} else {
+			quote, err := quoteDocument(db, &document)
+			if errors.Is(err, errNoPrice) {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "No pricing rule applies, please provide an amount"})
+			}
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compute quote"})
+			}
+			document.Quote = quote
+		}
+
+		// A coupon the customer applied is taken off the new price
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := reapplyCoupon(tx, &document); err != nil {
+				return err
+			}
+			return saveQuote(tx, &document)
+		})
+		if errors.Is(err, errQuoteLocked) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "The quote cannot change once it has been accepted"})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Quote updated", "word_count": document.WordCount, "quote": document.Quote})
+	}
+}
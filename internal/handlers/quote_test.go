@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestQuoteUpdatesCoverEveryQuoteColumn(t *testing.T) {
+	documentSchema, err := schema.Parse(&models.Document{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates := quoteUpdates(&models.Document{})
+	for _, field := range documentSchema.Fields {
+		if strings.HasPrefix(field.DBName, "quote_") {
+			if _, ok := updates[field.DBName]; !ok {
+				t.Errorf("saveQuote doesn't write %s", field.DBName)
+			}
+		}
+	}
+	for column := range updates {
+		if documentSchema.LookUpField(column) == nil {
+			t.Errorf("saveQuote writes unknown column %s", column)
+		}
+	}
+}
+
+func TestSaveQuoteDoesNotOverwriteAcceptedQuote(t *testing.T) {
+	db := databasetest.Open(t)
+	document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StateApproved)
+
+	// The customer accepts while an admin is requoting the copy loaded before
+	stale := document
+	if err := db.Model(&models.Document{}).Where("id = ?", document.ID).Update("state", models.StateQuoted).Error; err != nil {
+		t.Fatal(err)
+	}
+	stale.Quote.Amount = 250
+	if err := saveQuote(db, &stale); !errors.Is(err, errQuoteLocked) {
+		t.Fatalf("saveQuote = %v, want errQuoteLocked", err)
+	}
+
+	var saved models.Document
+	db.First(&saved, document.ID)
+	if saved.State != models.StateQuoted || saved.Quote.Amount != 100 {
+		t.Fatalf("accepted quote changed to %s at %.2f", saved.State, saved.Quote.Amount)
+	}
+}
+
+// createPricingRule stores an active pricing rule
+func createPricingRule(t *testing.T, db *gorm.DB, rule models.PricingRule) models.PricingRule {
+	t.Helper()
+	rule.Active = true
+	if err := db.Create(&rule).Error; err != nil {
+		t.Fatal(err)
+	}
+	return rule
+}
+
+func TestQuoteDocument(t *testing.T) {
+	db := databasetest.Open(t)
+	if err := db.Create(&models.Settings{PricePerWord: 0.05, TaxRate: 11}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.UrgencyTier{Code: "overnight", Name: "Overnight", Multiplier: 2}).Error; err != nil {
+		t.Fatal(err)
+	}
+	pair := createPricingRule(t, db, models.PricingRule{SourceLanguage: "en", TargetLanguage: "id", PricePerWord: 0.2})
+	category := createPricingRule(t, db, models.PricingRule{Category: models.CategoryEngineering, PricePerWord: 0.1, PricePerPage: 30})
+	exact := createPricingRule(t, db, models.PricingRule{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategorySocialSciences, PricePerWord: 0.3, MinimumCharge: 200})
+	inactive := createPricingRule(t, db, models.PricingRule{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategoryEngineering, PricePerWord: 1})
+	db.Model(&inactive).Update("active", false)
+
+	tests := []struct {
+		name     string
+		document models.Document
+		rule     uint
+		amount   float64
+	}{
+		{"language pair beats category", models.Document{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategoryEngineering, WordCount: 1000}, pair.ID, 200},
+		{"most specific with its minimum charge", models.Document{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategorySocialSciences, WordCount: 100}, exact.ID, 200},
+		{"category", models.Document{SourceLanguage: "fr", TargetLanguage: "id", Category: models.CategoryEngineering, WordCount: 1000}, category.ID, 100},
+		{"less specific rule with a page price", models.Document{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategoryEngineering, NumberOfPages: 2}, category.ID, 60},
+		{"global price per word", models.Document{SourceLanguage: "fr", TargetLanguage: "ja", Category: models.CategoryGeneral, WordCount: 1000}, 0, 50},
+		{"global price per page", models.Document{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategorySocialSciences, NumberOfPages: 2}, 0, 25},
+		{"urgency multiplier", models.Document{SourceLanguage: "fr", TargetLanguage: "ja", Category: models.CategoryGeneral, WordCount: 1000, Urgency: "overnight"}, 0, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := quoteDocument(db, &tt.document)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.RuleID != tt.rule || quote.Amount != tt.amount {
+				t.Fatalf("quoted %.2f with rule %d, want %.2f with rule %d", quote.Amount, quote.RuleID, tt.amount, tt.rule)
+			}
+			if quote.TaxRate != 11 {
+				t.Fatalf("tax rate %.2f", quote.TaxRate)
+			}
+		})
+	}
+}
+
+func TestQuoteDocumentFailures(t *testing.T) {
+	db := databasetest.Open(t)
+	document := models.Document{SourceLanguage: "en", TargetLanguage: "id", Category: models.CategoryGeneral, WordCount: 1000}
+
+	// Without settings there is no global price to fall back on
+	if _, err := quoteDocument(db, &document); !errors.Is(err, errNoPrice) {
+		t.Fatalf("quoteDocument = %v, want errNoPrice", err)
+	}
+
+	document.Urgency = "someday"
+	if _, err := quoteDocument(db, &document); !errors.Is(err, errUnknownUrgency) {
+		t.Fatalf("quoteDocument = %v, want errUnknownUrgency", err)
+	}
+}
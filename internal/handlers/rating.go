@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 )
 
+// SubmitRating lets the owner of a delivered document rate its translator once
 func SubmitRating(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var input struct {
@@ -22,6 +23,31 @@ func SubmitRating(db *gorm.DB) fiber.Handler {
 
 		userID := c.Locals("userID").(float64)
 
+		if input.Rating < 1 || input.Rating > 5 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "rating must be between 1 and 5"})
+		}
+
+		var document models.Document
+		if err := db.Where("id = ? AND user_id = ?", input.DocumentID, uint(userID)).First(&document).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "document not found"})
+		}
+
+		if document.State != models.StateDelivered {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "only delivered documents can be rated"})
+		}
+
+		if input.TranslatorID != document.TranslatorID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "translator did not translate this document"})
+		}
+
+		var count int64
+		if err := db.Model(&models.Rating{}).Where("document_id = ?", document.ID).Count(&count).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot check existing ratings"})
+		}
+		if count > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "document has already been rated"})
+		}
+
 		rating := models.Rating{
 			UserID:       uint(userID),
 			TranslatorID: input.TranslatorID,
@@ -40,10 +66,10 @@ func SubmitRating(db *gorm.DB) fiber.Handler {
 
 func GetRatings(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		documentID := c.Params("id")
+		document := c.Locals("document").(*models.Document)
 
 		var ratings []models.Rating
-		if err := db.Where("document_id = ?", documentID).Find(&ratings).Error; err != nil {
+		if err := db.Where("document_id = ?", document.ID).Find(&ratings).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot fetch ratings"})
 		}
 
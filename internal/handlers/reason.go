@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// errInvalidReasonBody is returned by parseReason for a body that isn't valid JSON
+var errInvalidReasonBody = errors.New("Cannot parse JSON")
+
+// parseReason reads the reason code and comment of a rejection or decline for the event
+// action. Both are optional, only a code that doesn't belong to the action is refused.
+func parseReason(c *fiber.Ctx, action string) (string, string, error) {
+	var input struct {
+		ReasonCode string `json:"reason_code"`
+		Comment    string `json:"comment"`
+	}
+	// Clients from before reason codes send no body at all
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return "", "", errInvalidReasonBody
+		}
+	}
+
+	if input.ReasonCode == "" {
+		return "", input.Comment, nil
+	}
+	if err := models.ValidateReason(action, input.ReasonCode, input.Comment); err != nil {
+		return "", "", err
+	}
+	return input.ReasonCode, input.Comment, nil
+}
+
+// reasonError responds to an invalid reason with the codes that can be used instead
+func reasonError(c *fiber.Ctx, action string, err error) error {
+	if errors.Is(err, errInvalidReasonBody) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	codes := make([]string, 0, len(models.ReasonCodes[action]))
+	for code := range models.ReasonCodes[action] {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error(), "reason_codes": codes})
+}
+
+// reasonText explains a reason in a notification, e.g. " Reason: The deadline is too short. Comment: ..."
+func reasonText(action, code, comment string) string {
+	var text string
+	if label, ok := models.ReasonCodes[action][code]; ok {
+		text = " Reason: " + label + "."
+	}
+	if comment != "" {
+		text += " Comment: " + comment
+	}
+	return text
+}
+
+// GetReasonCodes lists the reasons that can be given per kind of rejection or decline
+func GetReasonCodes() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(models.ReasonCodes)
+	}
+}
+
+// GetRejectionReasonReport counts the reasons given for rejections and declines per
+// translator, most common first. It takes the same action, translator_id, from and to
+// filters as the audit search.
+func GetRejectionReasonReport(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := db.Table("document_events").
+			Select("document_events.translator_id, COALESCE(users.username, '') AS translator_name, document_events.action, document_events.reason_code, COUNT(*) AS count").
+			Joins("LEFT JOIN users ON users.id = document_events.translator_id").
+			Where("document_events.reason_code <> ''")
+
+		if action := c.Query("action"); action != "" {
+			query = query.Where("document_events.action = ?", action)
+		}
+		if translatorID := c.QueryInt("translator_id"); translatorID > 0 {
+			query = query.Where("document_events.translator_id = ?", translatorID)
+		}
+		if from := c.Query("from"); from != "" {
+			fromTime, err := parseTimeQuery(from)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'from' date"})
+			}
+			query = query.Where("document_events.created_at >= ?", fromTime)
+		}
+		if to := c.Query("to"); to != "" {
+			toTime, err := parseTimeQuery(to)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'to' date"})
+			}
+			query = query.Where("document_events.created_at < ?", toTime)
+		}
+
+		var rows []struct {
+			TranslatorID   uint   `json:"translator_id"`
+			TranslatorName string `json:"translator_name"`
+			Action         string `json:"action"`
+			ReasonCode     string `json:"reason_code"`
+			Count          int64  `json:"count"`
+		}
+		if err := query.
+			Group("document_events.translator_id, users.username, document_events.action, document_events.reason_code").
+			Order("count desc, document_events.translator_id asc").
+			Scan(&rows).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build report"})
+		}
+
+		return c.JSON(rows)
+	}
+}
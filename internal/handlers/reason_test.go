@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// reasonApp answers with what parseReason read for a rejected translation
+func reasonApp() *fiber.App {
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		code, comment, err := parseReason(c, models.EventTranslationRejected)
+		if err != nil {
+			return reasonError(c, models.EventTranslationRejected, err)
+		}
+		return c.JSON(fiber.Map{"code": code, "comment": comment})
+	})
+	return app
+}
+
+func TestParseReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		code    string
+		comment string
+	}{
+		{"no body", "", http.StatusOK, "", ""},
+		{"empty object", `{}`, http.StatusOK, "", ""},
+		{"comment only", `{"comment":"Please check the tables"}`, http.StatusOK, "", "Please check the tables"},
+		{"valid code", `{"reason_code":"terminology","comment":"Use the glossary"}`, http.StatusOK, "terminology", "Use the glossary"},
+		{"code of another action", `{"reason_code":"unreadable"}`, http.StatusBadRequest, "", ""},
+		{"other without comment", `{"reason_code":"other"}`, http.StatusBadRequest, "", ""},
+		{"malformed body", `{"reason_code":`, http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := send(t, reasonApp(), http.MethodPost, "/", tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d: %v", status, tt.status, body)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			if body["code"] != tt.code || body["comment"] != tt.comment {
+				t.Fatalf("read code %q and comment %q, want %q and %q", body["code"], body["comment"], tt.code, tt.comment)
+			}
+		})
+	}
+}
+
+func TestReasonErrorListsCodesOnlyForUnknownCodes(t *testing.T) {
+	_, body := send(t, reasonApp(), http.MethodPost, "/", `{"reason_code":"unreadable"}`)
+	if codes, ok := body["reason_codes"].([]interface{}); !ok || len(codes) != len(models.ReasonCodes[models.EventTranslationRejected]) {
+		t.Fatalf("unknown code response %v doesn't list the translation rejection codes", body)
+	}
+
+	_, body = send(t, reasonApp(), http.MethodPost, "/", `not json`)
+	if _, ok := body["reason_codes"]; ok || body["error"] != "Cannot parse JSON" {
+		t.Fatalf("malformed body response %v", body)
+	}
+}
+
+func TestReasonText(t *testing.T) {
+	if got := reasonText(models.EventAssignmentDeclined, "", ""); got != "" {
+		t.Fatalf("no reason gave %q", got)
+	}
+	if got := reasonText(models.EventAssignmentDeclined, "", "On leave"); got != " Comment: On leave" {
+		t.Fatalf("comment only gave %q", got)
+	}
+	if got := reasonText(models.EventAssignmentDeclined, "no_capacity", ""); got != " Reason: Too much work at the moment." {
+		t.Fatalf("code only gave %q", got)
+	}
+}
+
+func TestRejectDocumentReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"no body", "", http.StatusOK, ""},
+		{"unknown code", `{"reason_code":"too_long"}`, http.StatusBadRequest, ""},
+		{"valid code", `{"reason_code":"duplicate"}`, http.StatusOK, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := databasetest.Open(t)
+			admin := createTestUser(t, db, "admin", models.RoleAdmin)
+			document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StateSubmitted)
+
+			app := fiber.New()
+			app.Post("/documents/:id/reject", as(admin), RejectDocument(db))
+			status, body := send(t, app, http.MethodPost, fmt.Sprintf("/documents/%d/reject", document.ID), tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d: %v", status, tt.status, body)
+			}
+
+			var events []models.DocumentEvent
+			db.Where("document_id = ? AND action = ?", document.ID, models.EventRejected).Find(&events)
+			if tt.status != http.StatusOK {
+				if len(events) != 0 {
+					t.Fatal("a refused rejection was recorded")
+				}
+				return
+			}
+			if len(events) != 1 || events[0].ReasonCode != tt.code {
+				t.Fatalf("recorded %+v, want one rejection with code %q", events, tt.code)
+			}
+		})
+	}
+}
+
+func TestDeclineAssignedDocumentReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"no body", "", http.StatusOK, ""},
+		{"unknown code", `{"reason_code":"unreadable"}`, http.StatusBadRequest, ""},
+		{"valid code", `{"reason_code":"no_capacity"}`, http.StatusOK, "no_capacity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := databasetest.Open(t)
+			translator := createTestUser(t, db, "translator", models.RoleTranslator)
+			document := createTestDocument(t, db, createTestUser(t, db, "owner", models.RoleUser), models.StateAssigned)
+			if err := db.Model(&document).Update("translator_id", translator.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			app := fiber.New()
+			app.Post("/documents/:id/decline", as(translator), DeclineAssignedDocument(db))
+			status, body := send(t, app, http.MethodPost, fmt.Sprintf("/documents/%d/decline", document.ID), tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d: %v", status, tt.status, body)
+			}
+
+			var events []models.DocumentEvent
+			db.Where("document_id = ? AND action = ?", document.ID, models.EventAssignmentDeclined).Find(&events)
+			if tt.status != http.StatusOK {
+				if len(events) != 0 {
+					t.Fatal("a refused decline was recorded")
+				}
+				return
+			}
+			if len(events) != 1 || events[0].ReasonCode != tt.code {
+				t.Fatalf("recorded %+v, want one decline with code %q", events, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"log"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// revisionTurnaround is how long a translator has to deliver a revision
+const revisionTurnaround = 3 * 24 * time.Hour
+
+// revisionPolicy returns how many days after delivery revisions can be asked for and how many rounds
+func revisionPolicy(db *gorm.DB) (int, int) {
+	var settings models.Settings
+	if err := db.First(&settings).Error; err != nil {
+		return models.DefaultRevisionWindowDays, models.DefaultMaxRevisionRounds
+	}
+	return settings.RevisionWindowDays, settings.MaxRevisionRounds
+}
+
+// RequestRevision sends a delivered document back to its translator with the customer's reason
+func RequestRevision(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		by := actorFrom(c)
+		document := c.Locals("document").(*models.Document)
+
+		if document.UserID != by.ID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the owner of the document can ask for a revision"})
+		}
+
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.Reason == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A reason is required"})
+		}
+
+		if document.State != models.StateDelivered || document.TranslatorID == 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Revisions can only be asked for delivered documents"})
+		}
+
+		windowDays, maxRounds := revisionPolicy(db)
+		deliveredAt := document.UpdatedAt
+		if document.DeliveredAt != nil {
+			deliveredAt = *document.DeliveredAt
+		}
+		if time.Now().After(deliveredAt.AddDate(0, 0, windowDays)) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "The revision window for this document has closed"})
+		}
+		if document.RevisionRounds >= maxRounds {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "No revision rounds left for this document"})
+		}
+
+		version, err := deliveredVersion(db, document.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to look up delivered versions"})
+		}
+
+		// The revision gets its own due date, the original one has passed
+		due := time.Now().Add(revisionTurnaround)
+		document.RevisionRounds++
+		document.DueAt = &due
+		document.EstimatedDelivery = &due
+		document.EscalationLevel = models.EscalationNone
+		if err := changeDocumentState(db, document, models.StateTranslating, by, models.EventRevisionRequested, input.Reason); err != nil {
+			return stateChangeError(c, err)
+		}
+
+		request := models.RevisionRequest{
+			DocumentID:    document.ID,
+			RequestedByID: by.ID,
+			Round:         document.RevisionRounds,
+			Version:       version,
+			Reason:        input.Reason,
+		}
+		if err := db.Create(&request).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save revision request"})
+		}
+
+		message := "The customer has asked for a revision: " + input.Reason
+		if err := CreateNotification(document.TranslatorID, document.ID, message, db); err != nil {
+			log.Printf("Failed to notify translator ID %d: %v", document.TranslatorID, err)
+		}
+		if err := notifyAdmins(document, "A customer has asked for a revision of a delivered document.", db); err != nil {
+			log.Printf("Failed to notify admins about document ID %d: %v", document.ID, err)
+		}
+
+		return c.JSON(request)
+	}
+}
+
+// GetRevisionRequests lists the revisions asked for on a document
+func GetRevisionRequests(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+
+		var requests []models.RevisionRequest
+		if err := db.Where("document_id = ?", document.ID).Order("round asc").Find(&requests).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch revision requests"})
+		}
+
+		return c.JSON(requests)
+	}
+}
+
+// UpdateRevisionPolicy sets the revision window and the number of revision rounds
+func UpdateRevisionPolicy(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			WindowDays int `json:"window_days"`
+			MaxRounds  int `json:"max_rounds"`
+		}
+
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+		if input.WindowDays < 0 || input.MaxRounds < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "window_days and max_rounds cannot be negative"})
+		}
+
+		var settings models.Settings
+		if err := db.FirstOrCreate(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load settings"})
+		}
+
+		settings.RevisionWindowDays = input.WindowDays
+		settings.MaxRevisionRounds = input.MaxRounds
+		if err := db.Save(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update settings"})
+		}
+
+		return c.JSON(fiber.Map{
+			"message":     "Revision policy updated successfully",
+			"window_days": settings.RevisionWindowDays,
+			"max_rounds":  settings.MaxRevisionRounds,
+		})
+	}
+}
@@ -17,16 +17,15 @@ func CheckAndDeclineUnconfirmedDocuments() {
 	var documents []models.Document
 	oneDayAgo := time.Now().Add(-24 * time.Hour)
 
-	if err := db.Where("translator_approval_status = ? AND assignment_time < ?", "Pending", oneDayAgo).Find(&documents).Error; err != nil {
+	if err := db.Where("state = ? AND assignment_time < ?", models.StateAssigned, oneDayAgo).Find(&documents).Error; err != nil {
 		log.Printf("Failed to fetch documents: %v", err)
 		return
 	}
 	for _, document := range documents {
-		document.TranslatorApprovalStatus = "Declined"
-		if err := db.Save(&document).Error; err != nil {
+		if err := releaseAssignment(db, &document, systemActor, models.EventAssignmentExpired, models.AssignmentExpired, models.ReasonNoResponse, "Translator did not respond within 24 hours"); err != nil {
 			log.Printf("Failed to update document ID %d: %v", document.ID, err)
-		} else {
-			log.Printf("Document ID %d automatically declined due to no confirmation from translator", document.ID)
+			continue
 		}
+		log.Printf("Document ID %d automatically declined due to no confirmation from translator", document.ID)
 	}
 }
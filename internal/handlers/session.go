@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	errRefreshTokenReused = errors.New("refresh token reused")
+	errSessionExpired     = errors.New("session expired")
+)
+
+// randomToken returns a URL-safe random token
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// newRefreshToken returns a random refresh token and the hash stored for it
+func newRefreshToken() (string, string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// startSession creates a session for a user who just logged in and returns its tokens
+func startSession(db *gorm.DB, c *fiber.Ctx, user models.User) (fiber.Map, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        now.Add(refreshTokenTTL),
+		LastUsedAt:       now,
+		UserAgent:        c.Get(fiber.HeaderUserAgent),
+		IP:               c.IP(),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return sessionTokens(user, session, refreshToken)
+}
+
+// sessionTokens builds the token part of the login and refresh responses
+func sessionTokens(user models.User, session models.Session, refreshToken string) (fiber.Map, error) {
+	token, err := generateJWT(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(accessTokenTTL.Seconds()),
+		"userRole":      user.Role,
+	}, nil
+}
+
+// revokeUserSessions signs a user out everywhere
+func revokeUserSessions(db *gorm.DB, userID uint) error {
+	return db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RefreshToken trades a refresh token for a new access token and a new refresh token.
+// A refresh token that was already rotated means it leaked, every session of the user is revoked.
+func RefreshToken(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.RefreshToken == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "refresh_token is required"})
+		}
+
+		presented := hashToken(input.RefreshToken)
+		newToken, newHash, err := newRefreshToken()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not generate token"})
+		}
+
+		var session models.Session
+		var user models.User
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("refresh_token_hash = ?", presented).First(&session).Error; err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+
+				// Not the current token, if it is an earlier one somebody else is using the session
+				if tx.Where("previous_token_hash = ?", presented).First(&session).Error == nil {
+					return errRefreshTokenReused
+				}
+				return errSessionExpired
+			}
+
+			if !session.Active() {
+				return errSessionExpired
+			}
+			if err := tx.First(&user, session.UserID).Error; err != nil {
+				return errSessionExpired
+			}
+
+			// Only rotate if nobody else rotated the same token concurrently
+			result := tx.Model(&models.Session{}).
+				Where("id = ? AND refresh_token_hash = ?", session.ID, presented).
+				Updates(map[string]interface{}{
+					"refresh_token_hash":  newHash,
+					"previous_token_hash": presented,
+					"last_used_at":        time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errSessionExpired
+			}
+			return nil
+		})
+
+		switch {
+		case errors.Is(err, errRefreshTokenReused):
+			// Revoked outside the transaction, which was rolled back
+			if err := revokeUserSessions(db, session.UserID); err != nil {
+				log.Printf("Failed to revoke sessions of user ID %d: %v", session.UserID, err)
+			}
+			log.Printf("Refresh token reuse detected on session ID %d, every session of user ID %d revoked", session.ID, session.UserID)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "session revoked"})
+		case errors.Is(err, errSessionExpired):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired refresh token"})
+		case err != nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not refresh session"})
+		}
+
+		tokens, err := sessionTokens(user, session, newToken)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not generate token"})
+		}
+		return c.JSON(tokens)
+	}
+}
+
+// Logout revokes the current session, or every session of the user when "all" is set
+func Logout(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+		sessionID, _ := c.Locals("sessionID").(float64)
+
+		var input struct {
+			All bool `json:"all"`
+		}
+		// The body is optional
+		_ = c.BodyParser(&input)
+
+		var err error
+		if input.All {
+			err = revokeUserSessions(db, uint(userID))
+		} else {
+			err = db.Model(&models.Session{}).
+				Where("id = ? AND user_id = ? AND revoked_at IS NULL", uint(sessionID), uint(userID)).
+				Update("revoked_at", time.Now()).Error
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not log out"})
+		}
+
+		return c.JSON(fiber.Map{"message": "logged out"})
+	}
+}
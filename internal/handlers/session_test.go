@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// sessionWithToken stores an active session of user whose refresh token is token
+func sessionWithToken(t *testing.T, db *gorm.DB, user models.User, token string) models.Session {
+	t.Helper()
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(token),
+		ExpiresAt:        time.Now().Add(time.Hour),
+		LastUsedAt:       time.Now(),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func refreshApp(db *gorm.DB) *fiber.App {
+	app := fiber.New()
+	app.Post("/token/refresh", RefreshToken(db))
+	return app
+}
+
+// refresh trades token on app and returns the status and the new refresh token
+func refresh(t *testing.T, app *fiber.App, token string) (int, string) {
+	t.Helper()
+	status, body := send(t, app, http.MethodPost, "/token/refresh", `{"refresh_token":"`+token+`"}`)
+	next, _ := body["refresh_token"].(string)
+	return status, next
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	session := sessionWithToken(t, db, user, "first")
+	app := refreshApp(db)
+
+	status, second := refresh(t, app, "first")
+	if status != http.StatusOK || second == "" || second == "first" {
+		t.Fatalf("refresh returned %d with refresh token %q", status, second)
+	}
+	status, third := refresh(t, app, second)
+	if status != http.StatusOK || third == "" || third == second {
+		t.Fatalf("refreshing with the rotated token returned %d with %q", status, third)
+	}
+
+	var saved models.Session
+	db.First(&saved, session.ID)
+	if saved.RefreshTokenHash != hashToken(third) || saved.PreviousTokenHash != hashToken(second) || saved.RevokedAt != nil {
+		t.Fatalf("session %+v after two rotations", saved)
+	}
+}
+
+func TestRefreshTokenReuseRevokesEverySession(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	other := createTestUser(t, db, "budi", models.RoleUser)
+	sessionWithToken(t, db, user, "stolen")
+	createTestSession(t, db, user)
+	createTestSession(t, db, other)
+	app := refreshApp(db)
+
+	status, rotated := refresh(t, app, "stolen")
+	if status != http.StatusOK {
+		t.Fatalf("refresh returned %d", status)
+	}
+
+	// The thief replays the token the user already traded in
+	if status, _ := refresh(t, app, "stolen"); status != http.StatusUnauthorized {
+		t.Fatalf("reusing a rotated token returned %d, want 401", status)
+	}
+	if n := activeSessions(t, db, user); n != 0 {
+		t.Fatalf("%d sessions of the user still active", n)
+	}
+	if n := activeSessions(t, db, other); n != 1 {
+		t.Fatal("the session of another user was revoked")
+	}
+	if status, _ := refresh(t, app, rotated); status != http.StatusUnauthorized {
+		t.Fatalf("the current token of a revoked session returned %d, want 401", status)
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+	user := createTestUser(t, db, "ayu", models.RoleUser)
+	expired := sessionWithToken(t, db, user, "expired")
+	db.Model(&expired).Update("expires_at", time.Now().Add(-time.Minute))
+	revoked := sessionWithToken(t, db, user, "revoked")
+	db.Model(&revoked).Update("revoked_at", time.Now())
+	app := refreshApp(db)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"missing", `{}`, http.StatusBadRequest},
+		{"unknown", `{"refresh_token":"unknown"}`, http.StatusUnauthorized},
+		{"expired", `{"refresh_token":"expired"}`, http.StatusUnauthorized},
+		{"revoked", `{"refresh_token":"revoked"}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := send(t, app, http.MethodPost, "/token/refresh", tt.body); status != tt.status {
+				t.Fatalf("status %d, want %d: %v", status, tt.status, body)
+			}
+		})
+	}
+}
@@ -29,4 +29,29 @@ func UpdatePricePerWord(db *gorm.DB) fiber.Handler {
 
 		return c.JSON(fiber.Map{"message": "Price updated successfully", "price_per_word": settings.PricePerWord})
 	}
-} 
\ No newline at end of file
+} 
+
+// UpdateAutoAssign turns automatic assignment of paid documents on or off
+func UpdateAutoAssign(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Enabled bool `json:"enabled"`
+		}
+
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+
+		var settings models.Settings
+		if err := db.FirstOrCreate(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load settings"})
+		}
+
+		settings.AutoAssign = input.Enabled
+		if err := db.Save(&settings).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update settings"})
+		}
+
+		return c.JSON(fiber.Map{"message": "Auto-assign updated successfully", "auto_assign": settings.AutoAssign})
+	}
+}
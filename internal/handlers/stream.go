@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+	"translation-app-backend/internal/middleware"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// streamHeartbeat keeps idle connections open through proxies, the session is checked again
+// on every beat. It is a variable so tests don't have to wait for it.
+var streamHeartbeat = 25 * time.Second
+
+// StreamEvents pushes new notifications and discussion messages to the user as Server-Sent Events.
+// The stream is closed once its session is revoked or expires, or the user is deleted or demoted.
+func StreamEvents(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+		userRole := c.Locals("userRole")
+		sessionID, _ := c.Locals("sessionID").(float64)
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		events, unsubscribe := realtime.Subscribe(uint(userID))
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+
+			heartbeat := time.NewTicker(streamHeartbeat)
+			defer heartbeat.Stop()
+
+			fmt.Fprint(w, ": connected\n\n")
+			for {
+				// A failed flush means the client went away
+				if err := w.Flush(); err != nil {
+					return
+				}
+
+				select {
+				case event := <-events:
+					data, err := json.Marshal(event.Data)
+					if err != nil {
+						log.Printf("Failed to encode %s event: %v", event.Type, err)
+						continue
+					}
+					fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+				case <-heartbeat.C:
+					if !middleware.SessionValid(db, sessionID, userID, userRole) {
+						return
+					}
+					fmt.Fprint(w, ": ping\n\n")
+				}
+			}
+		})
+
+		return nil
+	}
+}
+
+// publishNotifications pushes freshly created notifications to their recipients
+func publishNotifications(notifications []models.Notification) {
+	for _, notification := range notifications {
+		realtime.Publish([]uint{notification.UserID}, realtime.Event{Type: realtime.EventNotification, Data: notification})
+	}
+}
+
+// publishDiscussion pushes a new discussion message to everyone who can see the document:
+// its owner, its translator and the admins
+func publishDiscussion(db *gorm.DB, document *models.Document, discussion models.Discussion) {
+	var recipients []uint
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Pluck("id", &recipients).Error; err != nil {
+		log.Printf("Failed to load admins for discussion: %v", err)
+	}
+	recipients = append(recipients, document.UserID)
+	if document.TranslatorID != 0 {
+		recipients = append(recipients, document.TranslatorID)
+	}
+
+	realtime.Publish(recipients, realtime.Event{Type: realtime.EventDiscussion, Data: discussion})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// readLine waits for the next line of an event stream that isn't blank
+func readLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("the stream was closed")
+			}
+			if line != "" {
+				return line
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for the stream")
+		}
+	}
+}
+
+func TestStreamEventsUntilSessionIsRevoked(t *testing.T) {
+	db := databasetest.Open(t)
+	heartbeat := streamHeartbeat
+	streamHeartbeat = 50 * time.Millisecond
+	defer func() { streamHeartbeat = heartbeat }()
+
+	user := createTestUser(t, db, "owner", models.RoleUser)
+	session := createTestSession(t, db, user)
+
+	app := fiber.New()
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		c.Locals("sessionID", float64(session.ID))
+		return c.Next()
+	}, as(user), StreamEvents(db))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream answered %d with %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := make(chan string, 64)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	if line := readLine(t, lines); line != ": connected" {
+		t.Fatalf("stream opened with %q", line)
+	}
+
+	// The connection is subscribed before it says it is connected
+	realtime.Publish([]uint{user.ID}, realtime.Event{Type: realtime.EventNotification, Data: fiber.Map{"Message": "Quote ready"}})
+	for line := readLine(t, lines); line != "event: notification"; line = readLine(t, lines) {
+		if line != ": ping" {
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+	if line := readLine(t, lines); line != `data: {"Message":"Quote ready"}` {
+		t.Fatalf("notification data was %q", line)
+	}
+
+	// A valid session keeps getting heartbeats
+	if line := readLine(t, lines); line != ": ping" {
+		t.Fatalf("expected a heartbeat, got %q", line)
+	}
+
+	if err := db.Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if strings.HasPrefix(line, "event:") {
+				t.Fatalf("revoked session got %q", line)
+			}
+		case <-deadline:
+			t.Fatal("the stream of a revoked session stayed open")
+		}
+	}
+}
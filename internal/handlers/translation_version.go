@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+	"translation-app-backend/internal/textextract"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// addTranslationVersion records the translated file just uploaded to a document as its next version
+func addTranslationVersion(db *gorm.DB, document *models.Document, uploadedByID uint) error {
+	var latest int
+	if err := db.Model(&models.TranslationVersion{}).
+		Where("document_id = ?", document.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return err
+	}
+
+	return db.Create(&models.TranslationVersion{
+		DocumentID:   document.ID,
+		Version:      latest + 1,
+		FileKey:      document.TranslatedFileKey,
+		FileName:     document.TranslatedFileName,
+		UploadedByID: uploadedByID,
+		Verdict:      models.VersionPending,
+	}).Error
+}
+
+// deliveredVersion returns the number of the last version sent to the customer, 0 if none
+func deliveredVersion(db *gorm.DB, documentID uint) (int, error) {
+	var version int
+	err := db.Model(&models.TranslationVersion{}).
+		Where("document_id = ? AND verdict = ?", documentID, models.VersionApproved).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+// reviewTranslationVersion stores the admin's verdict on the version currently under review.
+// Approving delivers it and closes the revision request it answers.
+func reviewTranslationVersion(db *gorm.DB, document *models.Document, verdict string, by actor) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{
+			"verdict":        verdict,
+			"reviewed_by_id": by.ID,
+			"reviewed_at":    now,
+		}
+		if verdict == models.VersionApproved {
+			updates["delivered_at"] = now
+		}
+
+		result := tx.Model(&models.TranslationVersion{}).
+			Where("document_id = ? AND file_key = ? AND verdict = ?", document.ID, document.TranslatedFileKey, models.VersionPending).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Translations uploaded before versions were kept have no row yet
+		if result.RowsAffected == 0 && document.TranslatedFileKey != "" {
+			if err := addTranslationVersion(tx, document, document.TranslatorID); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.TranslationVersion{}).
+				Where("document_id = ? AND file_key = ? AND verdict = ?", document.ID, document.TranslatedFileKey, models.VersionPending).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if verdict != models.VersionApproved {
+			return nil
+		}
+		return tx.Model(&models.RevisionRequest{}).
+			Where("document_id = ? AND resolved_at IS NULL", document.ID).
+			Update("resolved_at", now).Error
+	})
+}
+
+// versionsVisibleTo limits the customer to the versions delivered to them, drafts are
+// only shown to the translator and admins
+func versionsVisibleTo(db *gorm.DB, document *models.Document, by actor) *gorm.DB {
+	query := db.Where("document_id = ?", document.ID)
+	if by.Role != models.RoleAdmin && document.TranslatorID != by.ID {
+		query = query.Where("verdict = ?", models.VersionApproved)
+	}
+	return query
+}
+
+// errInvalidVersion is returned for version numbers that aren't positive integers
+var errInvalidVersion = errors.New("invalid version number")
+
+// findTranslationVersion loads the version of the document with the given number
+func findTranslationVersion(db *gorm.DB, document *models.Document, by actor, number string) (*models.TranslationVersion, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return nil, errInvalidVersion
+	}
+
+	var version models.TranslationVersion
+	if err := versionsVisibleTo(db, document, by).Where("version = ?", n).First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// versionError responds to a failed findTranslationVersion
+func versionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errInvalidVersion):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Version not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch version"})
+	}
+}
+
+// GetTranslationVersions lists the translated versions of a document
+func GetTranslationVersions(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+
+		var versions []models.TranslationVersion
+		if err := versionsVisibleTo(db, document, actorFrom(c)).Order("version asc").Find(&versions).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch versions"})
+		}
+
+		return c.JSON(versions)
+	}
+}
+
+// DownloadTranslationVersion sends the file of one version
+func DownloadTranslationVersion(db *gorm.DB, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+
+		version, err := findTranslationVersion(db, document, actorFrom(c), c.Params("version"))
+		if err != nil {
+			return versionError(c, err)
+		}
+
+		return sendBlob(c, store, version.FileKey, version.FileName)
+	}
+}
+
+// DiffTranslationVersions compares the text of two versions line by line, ?from=1&to=2
+func DiffTranslationVersions(db *gorm.DB, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document := c.Locals("document").(*models.Document)
+
+		by := actorFrom(c)
+		from, err := findTranslationVersion(db, document, by, c.Query("from"))
+		if err != nil {
+			return versionError(c, err)
+		}
+		to, err := findTranslationVersion(db, document, by, c.Query("to"))
+		if err != nil {
+			return versionError(c, err)
+		}
+
+		var texts [2]string
+		for i, version := range []*models.TranslationVersion{from, to} {
+			if !textextract.Supported(version.FileName) {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Version " + strconv.Itoa(version.Version) + " is not a text or Word document"})
+			}
+			data, err := readBlob(c, store, version.FileKey)
+			if err != nil {
+				log.Printf("Failed to read blob %s: %v", version.FileKey, err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read file"})
+			}
+			texts[i], err = textextract.Extract(version.FileName, data)
+			if errors.Is(err, textextract.ErrTooLarge) {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Version " + strconv.Itoa(version.Version) + " is too large to compare"})
+			}
+			if err != nil {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Failed to read text of version " + strconv.Itoa(version.Version)})
+			}
+		}
+
+		lines, err := textextract.DiffLines(texts[0], texts[1])
+		if errors.Is(err, textextract.ErrDiffTooLarge) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compare versions"})
+		}
+
+		return c.JSON(fiber.Map{
+			"from":  from.Version,
+			"to":    to.Version,
+			"lines": lines,
+		})
+	}
+}
@@ -1,8 +1,9 @@
 package handlers
 
 import (
-	"io"
+	"log"
 	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -17,7 +18,7 @@ func GetAssignedDocuments(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch documents"})
 		}
 
-		return c.JSON(documents)
+		return c.JSON(translatorDocuments(documents))
 	}
 }
 
@@ -31,11 +32,11 @@ func GetAssignedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found or not assigned to you"})
 		}
 
-		return c.JSON(document)
+		return c.JSON(translatorDocument{Document: &document})
 	}
 }
 
-func DownloadAssignedDocument(db *gorm.DB) fiber.Handler {
+func DownloadAssignedDocument(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Locals("userID")
 		documentID := c.Params("id")
@@ -45,14 +46,11 @@ func DownloadAssignedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found or not assigned to you"})
 		}
 
-		if document.FileContent == nil {
+		if document.FileKey == "" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document file not found"})
 		}
 
-		c.Set("Content-Disposition", "attachment; filename="+document.FileName)
-		c.Set("Content-Type", "application/octet-stream")
-
-		return c.Send(document.FileContent)
+		return sendBlob(c, store, document.FileKey, document.FileName)
 	}
 }
 
@@ -66,10 +64,12 @@ func ApproveAssignedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found or not assigned to you"})
 		}
 
-		document.TranslatorApprovalStatus = "Accepted"
-		document.Status = "Translating"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		if err := changeDocumentState(db, &document, models.StateTranslating, actorFrom(c), models.EventAssignmentAccepted, ""); err != nil {
+			return stateChangeError(c, err)
+		}
+
+		if err := closeAssignmentAttempt(db, document.ID, document.TranslatorID, models.AssignmentAccepted); err != nil {
+			log.Printf("Failed to close assignment attempt for document ID %d: %v", document.ID, err)
 		}
 
 		var translator models.User
@@ -82,6 +82,11 @@ func ApproveAssignedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update translator status"})
 		}
 
+		// The work starts now, estimate with this translator's throughput
+		if err := refreshEstimate(db, &document, &translator); err != nil {
+			log.Printf("Failed to re-estimate document ID %d: %v", document.ID, err)
+		}
+
 		
 		message := "Your document is being translated."
 		if err := CreateNotification(document.UserID, document.ID, message, db); err != nil {
@@ -89,7 +94,7 @@ func ApproveAssignedDocument(db *gorm.DB) fiber.Handler {
 		}
 
 		message2 := "A translator has accepted to translate a document."
-		if err := CreateNotification(2, document.ID, message2, db); err != nil {
+		if err := notifyAdmins(&document, message2, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
 		
@@ -107,21 +112,21 @@ func DeclineAssignedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found or not assigned to you"})
 		}
 
-		document.TranslatorApprovalStatus = "Declined"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		code, comment, err := parseReason(c, models.EventAssignmentDeclined)
+		if err != nil {
+			return reasonError(c, models.EventAssignmentDeclined, err)
 		}
 
-		message2 := "A translator has refused to translate a document."
-		if err := CreateNotification(2, document.ID, message2, db); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
+		// Declining puts the document back in the unassigned pool
+		if err := releaseAssignment(db, &document, actorFrom(c), models.EventAssignmentDeclined, models.AssignmentDeclined, code, comment); err != nil {
+			return stateChangeError(c, err)
 		}
 
 		return c.JSON(fiber.Map{"message": "Document declined successfully"})
 	}
 }
 
-func UploadTranslatedDocument(db *gorm.DB) fiber.Handler {
+func UploadTranslatedDocument(db *gorm.DB, store storage.BlobStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Locals("userID")
 		documentID := c.Params("id")
@@ -131,32 +136,37 @@ func UploadTranslatedDocument(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found or not assigned to you"})
 		}
 
-		file, err := c.FormFile("translated_document")
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No file uploaded"})
+		if !document.State.CanTransitionTo(models.StateInReview) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Translations can only be uploaded while the document is being translated"})
 		}
 
-		fileContent, err := file.Open()
+		file, err := c.FormFile("translated_document")
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open file: " + err.Error()})
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No file uploaded"})
 		}
-		defer fileContent.Close()
 
-		/// Read the file data
-		fileData, err := io.ReadAll(fileContent)
+		key, err := saveUpload(c, store, "translations", file)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read file: " + err.Error()})
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to store file: " + err.Error()})
 		}
 
-		document.TranslatedFileContent = fileData
+		// Earlier uploads are kept as versions, so the previous file isn't deleted
+		document.TranslatedFileKey = key
 		document.TranslatedFileName = file.Filename
-		document.TranslatedApprovalStatus = "Pending"
-		if err := db.Save(&document).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update document"})
+		by := actorFrom(c)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := changeDocumentState(tx, &document, models.StateInReview, by, models.EventTranslationUploaded, ""); err != nil {
+				return err
+			}
+			return addTranslationVersion(tx, &document, by.ID)
+		})
+		if err != nil {
+			deleteBlob(c, store, key)
+			return stateChangeError(c, err)
 		}
 
 		message := "A translator has submited translated document."
-		if err := CreateNotification(2, document.ID, message, db); err != nil {
+		if err := notifyAdmins(&document, message, db); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err})
 		}
 
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"log"
+	"time"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UploadCredential lets a translator attach a CV or certificate to their application
+func UploadCredential(db *gorm.DB, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		credential := models.TranslatorCredential{
+			UserID: uint(userID),
+			Kind:   c.FormValue("kind"),
+		}
+		if err := credential.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No file uploaded"})
+		}
+
+		key, err := saveUpload(c, store, "credentials", file)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save file"})
+		}
+
+		credential.FileKey = key
+		credential.FileName = file.Filename
+		if err := db.Create(&credential).Error; err != nil {
+			deleteBlob(c, store, key)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save credential"})
+		}
+
+		return c.JSON(credential)
+	}
+}
+
+// GetVettingStatus shows a translator where their application stands and the tests they were given
+func GetVettingStatus(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+
+		var user models.User
+		if err := db.First(&user, uint(userID)).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+
+		var credentials []models.TranslatorCredential
+		if err := db.Where("user_id = ?", user.ID).Order("created_at asc").Find(&credentials).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch credentials"})
+		}
+
+		var tests []models.TestTranslation
+		if err := db.Where("translator_id = ?", user.ID).Order("created_at asc").Find(&tests).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch test translations"})
+		}
+
+		return c.JSON(fiber.Map{
+			"vetting_status":      user.VettingStatus,
+			"vetting_note":        user.VettingNote,
+			"vetting_reason_code": user.VettingReasonCode,
+			"credentials":         credentials,
+			"tests":               tests,
+		})
+	}
+}
+
+// SubmitTestTranslation hands in the translator's version of a test passage
+func SubmitTestTranslation(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("userID").(float64)
+		testID := c.Params("id")
+
+		var input struct {
+			Text string `json:"text"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.Text == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Translation text is required"})
+		}
+
+		var test models.TestTranslation
+		if err := db.Where("id = ? AND translator_id = ?", testID, uint(userID)).First(&test).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test translation not found"})
+		}
+
+		if test.SubmittedAt != nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Test translation has already been submitted"})
+		}
+
+		now := time.Now()
+		test.SubmittedText = input.Text
+		test.SubmittedAt = &now
+		if err := db.Save(&test).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to submit test translation"})
+		}
+
+		if err := notifyAllAdmins("A translator has submitted a test translation.", db); err != nil {
+			log.Printf("Failed to notify admins about test translation ID %d: %v", test.ID, err)
+		}
+
+		return c.JSON(fiber.Map{"message": "Test translation submitted successfully"})
+	}
+}
+
+// GetTranslatorApplications lists translator applications waiting for a decision, or
+// those with the status given in the query
+func GetTranslatorApplications(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		statuses := []string{models.VettingPendingReview, models.VettingTestAssigned}
+		if status := c.Query("status"); status != "" {
+			statuses = []string{status}
+		}
+
+		var translators []models.User
+		if err := db.Where("role = ? AND vetting_status IN ?", models.RoleTranslator, statuses).
+			Order("created_at asc").Find(&translators).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch applications"})
+		}
+
+		return c.JSON(translators)
+	}
+}
+
+// GetTranslatorApplication returns an application with its credentials and test translations
+func GetTranslatorApplication(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		translatorID := c.Params("id")
+
+		var translator models.User
+		if err := db.Where("id = ? AND role = ?", translatorID, models.RoleTranslator).First(&translator).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translator not found"})
+		}
+
+		var credentials []models.TranslatorCredential
+		if err := db.Where("user_id = ?", translator.ID).Order("created_at asc").Find(&credentials).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch credentials"})
+		}
+
+		var tests []models.TestTranslation
+		if err := db.Where("translator_id = ?", translator.ID).Order("created_at asc").Find(&tests).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch test translations"})
+		}
+
+		return c.JSON(fiber.Map{
+			"translator":  translator,
+			"credentials": credentials,
+			"tests":       tests,
+		})
+	}
+}
+
+// DownloadCredential lets admins read a CV or certificate of an applicant
+func DownloadCredential(db *gorm.DB, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var credential models.TranslatorCredential
+		if err := db.Where("id = ? AND user_id = ?", c.Params("credentialId"), c.Params("id")).First(&credential).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Credential not found"})
+		}
+
+		return sendBlob(c, store, credential.FileKey, credential.FileName)
+	}
+}
+
+// AssignTestTranslation gives an applicant a passage to translate before they are vetted
+func AssignTestTranslation(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		translatorID := c.Params("id")
+
+		var input struct {
+			SourceLanguage string `json:"source_language"`
+			TargetLanguage string `json:"target_language"`
+			SourceText     string `json:"source_text"`
+			Instructions   string `json:"instructions"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		if input.SourceLanguage == "" || input.TargetLanguage == "" || input.SourceText == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "source_language, target_language and source_text are required"})
+		}
+
+		var translator models.User
+		if err := db.Where("id = ? AND role = ?", translatorID, models.RoleTranslator).First(&translator).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translator not found"})
+		}
+
+		if translator.VettingStatus == models.VettingApproved || translator.VettingStatus == models.VettingRejected {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Application has already been decided"})
+		}
+
+		test := models.TestTranslation{
+			TranslatorID:   translator.ID,
+			AssignedByID:   actorFrom(c).ID,
+			SourceLanguage: input.SourceLanguage,
+			TargetLanguage: input.TargetLanguage,
+			SourceText:     input.SourceText,
+			Instructions:   input.Instructions,
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&test).Error; err != nil {
+				return err
+			}
+			return tx.Model(&translator).Update("vetting_status", models.VettingTestAssigned).Error
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to assign test translation"})
+		}
+
+		message := "You have been given a test translation to complete."
+		if err := CreateNotification(translator.ID, 0, message, db); err != nil {
+			log.Printf("Failed to notify translator ID %d: %v", translator.ID, err)
+		}
+
+		return c.JSON(test)
+	}
+}
+
+// ApproveTranslator vets a translator, from then on they are matched to documents
+func ApproveTranslator(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		// The note is optional
+		_ = c.BodyParser(&input)
+
+		message := "Your translator application has been approved."
+		if input.Reason != "" {
+			message += " " + input.Reason
+		}
+		return decideTranslatorApplication(c, db, models.VettingApproved, "", input.Reason, message)
+	}
+}
+
+// RejectTranslator turns a translator application down with one of the application reason codes
+func RejectTranslator(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code, comment, err := parseReason(c, models.ActionApplicationRejected)
+		if err == nil && code == "" {
+			// Unlike documents, applications never had a reasonless rejection to stay compatible with
+			err = models.ErrReasonRequired
+		}
+		if err != nil {
+			return reasonError(c, models.ActionApplicationRejected, err)
+		}
+
+		message := "Your translator application has been rejected." + reasonText(models.ActionApplicationRejected, code, comment)
+		return decideTranslatorApplication(c, db, models.VettingRejected, code, comment, message)
+	}
+}
+
+func decideTranslatorApplication(c *fiber.Ctx, db *gorm.DB, status, reasonCode, note, message string) error {
+	translatorID := c.Params("id")
+
+	var translator models.User
+	if err := db.Where("id = ? AND role = ?", translatorID, models.RoleTranslator).First(&translator).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Translator not found"})
+	}
+
+	// Only applications still under review or waiting on a test can be decided
+	if translator.VettingStatus != models.VettingPendingReview && translator.VettingStatus != models.VettingTestAssigned {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Application has already been decided"})
+	}
+
+	if err := db.Model(&translator).Updates(map[string]interface{}{
+		"vetting_status":      status,
+		"vetting_note":        note,
+		"vetting_reason_code": reasonCode,
+	}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update application"})
+	}
+
+	if err := CreateNotification(translator.ID, 0, message, db); err != nil {
+		log.Printf("Failed to notify translator ID %d: %v", translator.ID, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Application updated", "vetting_status": status})
+}
+
+// GetApplicationRejectionReport counts rejected translator applications per reason code, most common first
+func GetApplicationRejectionReport(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var rows []struct {
+			ReasonCode string `json:"reason_code"`
+			Count      int64  `json:"count"`
+		}
+		if err := db.Model(&models.User{}).
+			Select("vetting_reason_code AS reason_code, COUNT(*) AS count").
+			Where("role = ? AND vetting_status = ? AND vetting_reason_code <> ''", models.RoleTranslator, models.VettingRejected).
+			Group("vetting_reason_code").
+			Order("count desc, vetting_reason_code asc").
+			Scan(&rows).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build report"})
+		}
+
+		return c.JSON(rows)
+	}
+}
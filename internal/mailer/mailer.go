@@ -0,0 +1,153 @@
+// Package mailer renders notification emails and delivers them over SMTP
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is a rendered email ready to be sent
+type Message struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Sender delivers a single email
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPSender sends mail through an SMTP relay. Authentication is skipped when no
+// username is set, which is how local stand-ins like MailHog are usually run.
+type SMTPSender struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPSenderFromEnv configures an SMTPSender from the SMTP_* environment variables
+func NewSMTPSenderFromEnv() (*SMTPSender, error) {
+	host := os.Getenv("SMTP_HOST")
+	from := os.Getenv("SMTP_FROM")
+	if host == "" || from == "" {
+		return nil, errors.New("SMTP_HOST and SMTP_FROM must be set")
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	return &SMTPSender{
+		Addr:     net.JoinHostPort(host, port),
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	body, err := buildMIME(s.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return err
+	}
+
+	// net/smtp has no context support, bound the whole exchange instead
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.Addr, auth, from.Address, []string{msg.To}, body)
+	}()
+
+	timeout := time.NewTimer(time.Minute)
+	defer timeout.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout.C:
+		return errors.New("smtp send timed out")
+	}
+}
+
+// buildMIME assembles a multipart/alternative message with text and HTML parts
+func buildMIME(from string, msg Message) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return nil, errors.New("invalid recipient address")
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.TextBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"From: " + from,
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"translation-app-backend/internal/mailer/mailertest"
+)
+
+func TestRenderEveryTemplate(t *testing.T) {
+	data := TemplateData{
+		Username:      "Ayu",
+		DocumentID:    42,
+		DocumentTitle: "Annual report",
+		AppURL:        "https://app.test",
+		Link:          "https://app.test/verify?token=abc",
+	}
+
+	for kind, subject := range subjects {
+		msg, err := Render(kind, data)
+		if err != nil {
+			t.Errorf("Render(%q): %v", kind, err)
+			continue
+		}
+		if msg.Subject != subject {
+			t.Errorf("Render(%q) subject = %q, want %q", kind, msg.Subject, subject)
+		}
+		for _, body := range []string{msg.TextBody, msg.HTMLBody} {
+			if !strings.Contains(body, "Ayu") {
+				t.Errorf("Render(%q) body doesn't greet the user: %q", kind, body)
+			}
+			if strings.Contains(body, "<no value>") {
+				t.Errorf("Render(%q) refers to a missing field: %q", kind, body)
+			}
+		}
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render("document_approved", TemplateData{DocumentTitle: "<script>alert(1)</script>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(msg.HTMLBody, "<script>") {
+		t.Fatalf("HTML body contains the raw title: %q", msg.HTMLBody)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if HasTemplate("no_such_kind") {
+		t.Fatal("HasTemplate reported an unknown kind")
+	}
+	if _, err := Render("no_such_kind", TemplateData{}); err == nil {
+		t.Fatal("Render of an unknown kind succeeded")
+	}
+}
+
+func TestSMTPSenderDelivers(t *testing.T) {
+	server, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	host, _, _ := strings.Cut(server.Addr, ":")
+	sender := &SMTPSender{Addr: server.Addr, Host: host, From: "Lekamantra <noreply@lekamantra.test>"}
+	msg := Message{To: "ayu@example.test", Subject: "Terjemahan siap ✓", TextBody: "plain body", HTMLBody: "<p>html body</p>"}
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	received := server.Received()
+	if len(received) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(received))
+	}
+	if received[0].From != "noreply@lekamantra.test" || len(received[0].To) != 1 || received[0].To[0] != "ayu@example.test" {
+		t.Fatalf("unexpected envelope %+v", received[0])
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(received[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Fatalf("subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		bodies = append(bodies, string(body))
+	}
+	if len(bodies) != 2 || bodies[0] != msg.TextBody || bodies[1] != msg.HTMLBody {
+		t.Fatalf("unexpected parts %q", bodies)
+	}
+}
+
+func TestSMTPSenderReportsRejection(t *testing.T) {
+	server, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.RejectNext(1)
+
+	host, _, _ := strings.Cut(server.Addr, ":")
+	sender := &SMTPSender{Addr: server.Addr, Host: host, From: "noreply@lekamantra.test"}
+	if err := sender.Send(context.Background(), Message{To: "ayu@example.test", TextBody: "x"}); err == nil {
+		t.Fatal("Send succeeded although the server rejected the message")
+	}
+}
+
+func TestBuildMIMERejectsHeaderInjection(t *testing.T) {
+	if _, err := buildMIME("noreply@lekamantra.test", Message{To: "a@example.test\r\nBcc: b@example.test"}); err == nil {
+		t.Fatal("buildMIME accepted a recipient with a line break")
+	}
+}
@@ -0,0 +1,143 @@
+// Package mailertest provides an in-process SMTP server for tests, a stand-in for
+// MailHog that records what it receives
+package mailertest
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Mail is a message received by the server
+type Mail struct {
+	From string
+	To   []string
+	Data string // Headers and body as sent, without the terminating dot
+}
+
+// Server accepts mail on a local port. While Reject is above zero, every message is
+// refused with a temporary failure and Reject is decremented.
+type Server struct {
+	Addr string
+
+	listener net.Listener
+	mu       sync.Mutex
+	reject   int
+	received []Mail
+}
+
+// NewServer starts a server on a free local port, stop it with Close
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{Addr: listener.Addr().String(), listener: listener}
+	go s.serve()
+	return s, nil
+}
+
+// RejectNext makes the server refuse the next n messages
+func (s *Server) RejectNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject = n
+}
+
+// Received returns the messages accepted so far
+func (s *Server) Received() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.received...)
+}
+
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 mailertest ready")
+	var mail Mail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 mailertest")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			mail = Mail{From: address(line)}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			mail.To = append(mail.To, address(line))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			mail.Data = data.String()
+
+			s.mu.Lock()
+			rejected := s.reject > 0
+			if rejected {
+				s.reject--
+			} else {
+				s.received = append(s.received, mail)
+			}
+			s.mu.Unlock()
+
+			if rejected {
+				reply("451 Temporary failure, try again later")
+			} else {
+				reply("250 OK queued")
+			}
+		case command == "RSET", command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// address takes the address out of a MAIL FROM or RCPT TO command
+func address(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
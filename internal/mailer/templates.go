@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFiles embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt"))
+)
+
+// subjects holds the subject line of every email that has a template, notification types
+// and the account emails
+var subjects = map[string]string{
+	"verify_email":        "Confirm your email address",
+	"password_reset":      "Reset your password",
+	"document_approved":   "Your document has been approved",
+	"document_assigned":   "A document has been assigned to you",
+	"document_translated": "Your translation is ready",
+	"payment_approved":    "Your payment has been approved",
+}
+
+// TemplateData is what email templates can refer to
+type TemplateData struct {
+	Username      string
+	DocumentID    uint
+	DocumentTitle string
+	Message       string
+	AppURL        string
+	Link          string // Action link of account emails
+}
+
+// HasTemplate reports whether a notification type is sent by email
+func HasTemplate(kind string) bool {
+	_, ok := subjects[kind]
+	return ok
+}
+
+// Render builds the email for a notification type, the recipient is left empty
+func Render(kind string, data TemplateData) (Message, error) {
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, kind+".txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, kind+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject:  subjects[kind],
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
 )
 
 // func Protected() fiber.Handler {
@@ -25,7 +27,8 @@ import (
 //     }
 // }
 
-func Authenticated() fiber.Handler {
+// Authenticated validates the access token and checks that its session is still valid
+func Authenticated(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 
@@ -33,27 +36,73 @@ func Authenticated() fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or missing Authorization header"})
 		}
 
-		tokenString := authHeader[7:]
+		return authenticate(c, db, authHeader[7:])
+	}
+}
+
+// AuthenticatedStream is Authenticated for streaming endpoints. Browsers can't set headers
+// on an EventSource, so the token may also be passed as the access_token query parameter.
+func AuthenticatedStream(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if len(authHeader) >= 7 && authHeader[:7] == "Bearer " {
+			return authenticate(c, db, authHeader[7:])
+		}
+
+		tokenString := c.Query("access_token")
+		if tokenString == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or missing access token"})
+		}
+
+		return authenticate(c, db, tokenString)
+	}
+}
 
-		token, err := jwt.ParseWithClaims(tokenString, &jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("SECRET")), nil
-		})
+// authenticate validates the JWT and stores the user it belongs to in Locals
+func authenticate(c *fiber.Ctx, db *gorm.DB, tokenString string) error {
+	token, err := jwt.ParseWithClaims(tokenString, &jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("SECRET")), nil
+	})
 
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized - Invalid token"})
+	if err != nil || !token.Valid {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized - Invalid token"})
+	}
+
+	if claims, ok := token.Claims.(*jwt.MapClaims); ok && token.Valid {
+		userID := (*claims)["user_id"]
+		userRole := (*claims)["userRole"]
+		if userID == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized - User ID not found in token"})
 		}
 
-		if claims, ok := token.Claims.(*jwt.MapClaims); ok && token.Valid {
-			userID := (*claims)["user_id"]
-			userRole := (*claims)["userRole"]
-			if userID == nil {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized - User ID not found in token"})
-			}
-			c.Locals("userID", userID)
-			c.Locals("userRole", userRole)
-			return c.Next()
+		// Tokens are revoked with their session, and deleted or demoted users lose access right away
+		sessionID, _ := (*claims)["sid"].(float64)
+		if !SessionValid(db, sessionID, userID, userRole) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized - Session expired or revoked"})
 		}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		c.Locals("userID", userID)
+		c.Locals("userRole", userRole)
+		c.Locals("sessionID", sessionID)
+		return c.Next()
 	}
+
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+}
+
+// SessionValid checks that the session of a token is active and still belongs to an
+// existing user with the role the token was issued for
+func SessionValid(db *gorm.DB, sessionID float64, userID interface{}, userRole interface{}) bool {
+	if sessionID == 0 {
+		return false
+	}
+
+	var count int64
+	err := db.Table("sessions").
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+		Where("sessions.id = ? AND sessions.user_id = ? AND sessions.deleted_at IS NULL", uint(sessionID), userID).
+		Where("sessions.revoked_at IS NULL AND sessions.expires_at > ?", time.Now()).
+		Where("users.role = ?", userRole).
+		Count(&count).Error
+	return err == nil && count > 0
 }
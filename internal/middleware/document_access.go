@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"translation-app-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// DocumentAccess lets only the owner, the assigned translator or an admin reach a
+// /documents/:id route. The loaded document is stored in Locals as "document".
+func DocumentAccess(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("userID").(float64)
+		role, _ := c.Locals("userRole").(string)
+
+		var document models.Document
+		if err := db.Where("id = ?", c.Params("id")).First(&document).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		}
+
+		// Answer as if the document didn't exist so other customers' IDs can't be probed
+		if !CanAccessDocument(&document, uint(userID), role) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Document not found"})
+		}
+
+		c.Locals("document", &document)
+		return c.Next()
+	}
+}
+
+// CanAccessDocument reports whether a user may see a document and take part in its discussion
+func CanAccessDocument(document *models.Document, userID uint, role string) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	return userID != 0 && (document.UserID == userID || document.TranslatorID == userID)
+}
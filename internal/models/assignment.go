@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AssignmentPending   = "Pending"
+	AssignmentAccepted  = "Accepted"
+	AssignmentDeclined  = "Declined"
+	AssignmentExpired   = "Expired"
+	AssignmentCancelled = "Cancelled"
+)
+
+// AssignmentAttempt records one offer of a document to a translator and how it ended
+type AssignmentAttempt struct {
+	gorm.Model
+	DocumentID   uint   `gorm:"not null;index"`
+	TranslatorID uint   `gorm:"not null;index"`
+	AssignedByID uint   // 0 when the auto-assign engine picked the translator
+	Outcome      string `gorm:"not null"`
+	RespondedAt  *time.Time
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTimeZone is used for working hours of translators who didn't pick a time zone
+const DefaultTimeZone = "Asia/Jakarta"
+
+// TimeOff is a period a translator is away and gets no new assignments
+type TimeOff struct {
+	gorm.Model
+	UserID   uint      `gorm:"not null;index"`
+	StartsAt time.Time `gorm:"not null"`
+	EndsAt   time.Time `gorm:"not null"`
+	Reason   string
+}
+
+func (t *TimeOff) Validate() error {
+	if t.StartsAt.IsZero() || t.EndsAt.IsZero() {
+		return errors.New("start and end are required")
+	}
+	if !t.EndsAt.After(t.StartsAt) {
+		return errors.New("time off must end after it starts")
+	}
+	return nil
+}
+
+// WorkingHours is a weekly slot a translator takes assignments in, in the translator's time zone.
+// A translator without any slots can be assigned at any time.
+type WorkingHours struct {
+	gorm.Model
+	UserID    uint   `gorm:"not null;index"`
+	Weekday   int    // 0 is Sunday, as in time.Weekday
+	StartTime string `gorm:"not null"` // "15:04"
+	EndTime   string `gorm:"not null"`
+}
+
+// Validate checks the slot and stores its times as zero padded "15:04", which WorkingAt compares as strings
+func (w *WorkingHours) Validate() error {
+	if w.Weekday < 0 || w.Weekday > 6 {
+		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	start, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return errors.New("start time must look like 09:00")
+	}
+	end, err := time.Parse("15:04", w.EndTime)
+	if err != nil {
+		return errors.New("end time must look like 17:00")
+	}
+	if !end.After(start) {
+		return errors.New("working hours must end after they start")
+	}
+	w.StartTime = start.Format("15:04")
+	w.EndTime = end.Format("15:04")
+	return nil
+}
+
+// WorkingAt reports whether t falls in one of the weekly slots, read in the given time zone
+func WorkingAt(hours []WorkingHours, timeZone string, t time.Time) bool {
+	if len(hours) == 0 {
+		return true
+	}
+
+	if timeZone == "" {
+		timeZone = DefaultTimeZone
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		location, _ = time.LoadLocation(DefaultTimeZone)
+	}
+	if location != nil {
+		t = t.In(location)
+	}
+
+	clock := t.Format("15:04")
+	for _, slot := range hours {
+		if time.Weekday(slot.Weekday) == t.Weekday() && clock >= slot.StartTime && clock < slot.EndTime {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkingHoursValidateNormalizesTimes(t *testing.T) {
+	hours := WorkingHours{Weekday: int(time.Monday), StartTime: "9:00", EndTime: "17:30"}
+	if err := hours.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if hours.StartTime != "09:00" || hours.EndTime != "17:30" {
+		t.Fatalf("stored %q to %q, want 09:00 to 17:30", hours.StartTime, hours.EndTime)
+	}
+
+	// 10:15 on a Monday must fall in the slot, which a raw "9:00" would have compared wrongly
+	monday := time.Date(2024, time.January, 1, 10, 15, 0, 0, time.UTC)
+	if !WorkingAt([]WorkingHours{hours}, "UTC", monday) {
+		t.Fatal("10:15 is outside 9:00 to 17:30")
+	}
+}
+
+func TestWorkingHoursValidateRejectsInvalidSlots(t *testing.T) {
+	for _, hours := range []WorkingHours{
+		{Weekday: 7, StartTime: "09:00", EndTime: "17:00"},
+		{Weekday: 1, StartTime: "nine", EndTime: "17:00"},
+		{Weekday: 1, StartTime: "17:00", EndTime: "09:00"},
+	} {
+		if err := hours.Validate(); err == nil {
+			t.Errorf("Validate accepted %+v", hours)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultCancellationRefundPercent is refunded when a paid document is cancelled after assignment
+	DefaultCancellationRefundPercent = 50
+	// DefaultCancellationCompensationPercent of the price goes to a translator who already started
+	DefaultCancellationCompensationPercent = 30
+)
+
+// Cancellation records a cancelled document and what is owed because of it
+type Cancellation struct {
+	gorm.Model
+	DocumentID    uint          `gorm:"not null;uniqueIndex"`
+	RequestedByID uint          `gorm:"not null"`
+	FromState     DocumentState `gorm:"not null"` // State the document was cancelled in, decides the terms
+	Reason        string        `gorm:"type:text"`
+	AmountPaid    float64       // Price the customer paid, 0 when cancelled before payment
+	RefundDue     float64       // Amount to refund to the customer
+	TranslatorID  uint          // Translator who was working on the document, 0 if none
+	Compensation  float64       // Amount owed to the translator for work already done
+}
+
+// Refund is money an admin sent back to the customer of a cancelled document
+type Refund struct {
+	gorm.Model
+	DocumentID     uint    `gorm:"not null;index"`
+	CancellationID uint    `gorm:"not null;index"`
+	Amount         float64 `gorm:"not null"`
+	Method         string  // e.g. "bank_transfer"
+	Reference      string  // Transaction number of the transfer
+	ProofKey       string  // Blob store key of the transfer proof
+	ProofFileName  string
+	RecordedByID   uint `gorm:"not null"`
+}
+
+// CancellationTerms works out what cancelling a document in its current state costs. Cancelling
+// is free before payment, a paid document that wasn't assigned yet is refunded in full, and
+// once a translator is involved only refundPercent is refunded. Delivered documents can't be cancelled,
+// and one that is back in revision after a delivery is cancelled without a refund or compensation.
+func CancellationTerms(d *Document, refundPercent, compensationPercent int) (Cancellation, error) {
+	if !d.State.CanTransitionTo(StateCancelled) {
+		return Cancellation{}, fmt.Errorf("%w: a %s document can't be cancelled", ErrInvalidTransition, d.State)
+	}
+
+	terms := Cancellation{DocumentID: d.ID, FromState: d.State}
+	switch d.State {
+	case StateSubmitted, StateApproved, StateQuoted:
+		return terms, nil
+	case StatePaid:
+		terms.AmountPaid = d.Quote.Amount
+		terms.RefundDue = d.Quote.Amount
+	case StateAssigned:
+		// The translator hasn't accepted yet, there is no work to pay for
+		terms.AmountPaid = d.Quote.Amount
+		terms.RefundDue = RoundMoney(d.Quote.Amount * float64(refundPercent) / 100)
+		terms.TranslatorID = d.TranslatorID
+	default:
+		terms.AmountPaid = d.Quote.Amount
+		terms.RefundDue = RoundMoney(d.Quote.Amount * float64(refundPercent) / 100)
+		terms.TranslatorID = d.TranslatorID
+		terms.Compensation = RoundMoney(d.Quote.Amount * float64(compensationPercent) / 100)
+	}
+
+	// The translation was already delivered once, the customer received what they paid for
+	// and the translator was credited when it was approved
+	if d.DeliveredAt != nil || d.RevisionRounds > 0 {
+		terms.RefundDue = 0
+		terms.Compensation = 0
+	}
+	return terms, nil
+}
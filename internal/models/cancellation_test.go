@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCancellationTerms(t *testing.T) {
+	delivered := time.Now()
+
+	tests := []struct {
+		name         string
+		document     Document
+		refund       float64
+		compensation float64
+	}{
+		{"before payment", Document{State: StateQuoted}, 0, 0},
+		{"paid", Document{State: StatePaid}, 100, 0},
+		{"assigned", Document{State: StateAssigned, TranslatorID: 7}, 50, 0},
+		{"translating", Document{State: StateTranslating, TranslatorID: 7}, 50, 30},
+		{"in revision after delivery", Document{State: StateTranslating, TranslatorID: 7, DeliveredAt: &delivered, RevisionRounds: 1}, 0, 0},
+		{"delivered before, no revision yet", Document{State: StateInReview, TranslatorID: 7, DeliveredAt: &delivered}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.document.Quote.Amount = 100
+			terms, err := CancellationTerms(&tt.document, DefaultCancellationRefundPercent, DefaultCancellationCompensationPercent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if terms.RefundDue != tt.refund || terms.Compensation != tt.compensation {
+				t.Fatalf("refund %.2f and compensation %.2f, want %.2f and %.2f", terms.RefundDue, terms.Compensation, tt.refund, tt.compensation)
+			}
+		})
+	}
+}
+
+func TestDeliveredDocumentCannotBeCancelled(t *testing.T) {
+	if _, err := CancellationTerms(&Document{State: StateDelivered}, DefaultCancellationRefundPercent, DefaultCancellationCompensationPercent); err == nil {
+		t.Fatal("cancelling a delivered document succeeded")
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of coupon
+const (
+	CouponPercent = "percent" // Value percent off the quoted price
+	CouponFixed   = "fixed"   // Value off the quoted price
+)
+
+var (
+	ErrCouponNotValid      = errors.New("this coupon is not valid at the moment")
+	ErrCouponNotApplicable = errors.New("this coupon does not apply to this document")
+	ErrCouponUsedUp        = errors.New("this coupon has been used up")
+	ErrCouponAlreadyUsed   = errors.New("you have already used this coupon")
+	ErrCouponTooLarge      = errors.New("this coupon is worth more than the price of the document")
+)
+
+// Coupon is a discount code customers apply to the quote of a document before paying.
+// Empty restrictions and zero limits match anything.
+type Coupon struct {
+	gorm.Model
+	Code           string `gorm:"not null;uniqueIndex"` // Stored upper case, matched case-insensitively
+	Description    string
+	Kind           string  `gorm:"not null"`
+	Value          float64 `gorm:"not null"`
+	ValidFrom      *time.Time
+	ValidUntil     *time.Time
+	MaxUses        int // Redemptions allowed in total
+	MaxUsesPerUser int // Redemptions allowed per customer
+	Category       string
+	SourceLanguage string
+	TargetLanguage string
+	Active         bool
+}
+
+// CouponRedemption is a coupon applied to a document. Redemptions on cancelled documents
+// don't count towards the limits of the coupon.
+type CouponRedemption struct {
+	gorm.Model
+	CouponID   uint    `gorm:"not null;index"`
+	UserID     uint    `gorm:"not null;index"`
+	DocumentID uint    `gorm:"not null;uniqueIndex"` // A document takes one coupon
+	Discount   float64 `gorm:"not null"`
+}
+
+// NormalizeCouponCode makes codes typed by customers match the stored ones
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (c *Coupon) Validate() error {
+	if c.Code == "" {
+		return errors.New("code is required")
+	}
+	switch c.Kind {
+	case CouponPercent:
+		if c.Value <= 0 || c.Value >= 100 {
+			return errors.New("a percentage discount must be between 0 and 100")
+		}
+	case CouponFixed:
+		if c.Value <= 0 {
+			return errors.New("a fixed discount must be positive")
+		}
+	default:
+		return errors.New("kind must be 'percent' or 'fixed'")
+	}
+	if c.Category != "" {
+		switch c.Category {
+		case CategoryGeneral, CategoryEngineering, CategorySocialSciences:
+		default:
+			return errors.New("invalid category: must be one of 'general', 'engineering', or 'social sciences'")
+		}
+	}
+	if c.ValidFrom != nil && c.ValidUntil != nil && !c.ValidUntil.After(*c.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+	if c.MaxUses < 0 || c.MaxUsesPerUser < 0 {
+		return errors.New("usage limits cannot be negative")
+	}
+	return nil
+}
+
+// AppliesTo checks that the coupon can be applied to the document at now. Usage limits
+// are checked separately since they depend on other redemptions.
+func (c *Coupon) AppliesTo(d *Document, now time.Time) error {
+	if !c.Active || (c.ValidFrom != nil && now.Before(*c.ValidFrom)) || (c.ValidUntil != nil && !now.Before(*c.ValidUntil)) {
+		return ErrCouponNotValid
+	}
+	for _, field := range []struct{ coupon, document string }{
+		{c.Category, d.Category},
+		{c.SourceLanguage, d.SourceLanguage},
+		{c.TargetLanguage, d.TargetLanguage},
+	} {
+		if field.coupon != "" && field.coupon != field.document {
+			return ErrCouponNotApplicable
+		}
+	}
+	if c.Kind == CouponFixed && c.Value >= d.Quote.Price() {
+		return ErrCouponTooLarge
+	}
+	return nil
+}
+
+// DiscountOn returns what the coupon takes off a price, never the whole price
+func (c *Coupon) DiscountOn(price float64) float64 {
+	discount := c.Value
+	if c.Kind == CouponPercent {
+		discount = price * c.Value / 100
+	}
+	discount = RoundMoney(discount)
+	if discount >= price {
+		return 0
+	}
+	return discount
+}
+
+// ApplyCoupon takes the discount of a coupon off the quote of the document
+func (d *Document) ApplyCoupon(c *Coupon) float64 {
+	price := d.Quote.Price()
+	d.Quote.Discount = c.DiscountOn(price)
+	d.Quote.CouponCode = c.Code
+	d.Quote.Amount = RoundMoney(price - d.Quote.Discount)
+	return d.Quote.Discount
+}
+
+// RemoveCoupon restores the quoted price without a discount
+func (d *Document) RemoveCoupon() {
+	d.Quote.Amount = d.Quote.Price()
+	d.Quote.Discount = 0
+	d.Quote.CouponCode = ""
+}
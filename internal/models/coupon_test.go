@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDiscountOn(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		price    float64
+		discount float64
+	}{
+		{"percent", Coupon{Kind: CouponPercent, Value: 10}, 250, 25},
+		{"percent rounded to cents", Coupon{Kind: CouponPercent, Value: 15}, 33.33, 5},
+		{"fixed", Coupon{Kind: CouponFixed, Value: 20}, 250, 20},
+		{"fixed worth the whole price", Coupon{Kind: CouponFixed, Value: 250}, 250, 0},
+		{"fixed worth more than the price", Coupon{Kind: CouponFixed, Value: 300}, 250, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.DiscountOn(tt.price); got != tt.discount {
+				t.Fatalf("discount %.2f, want %.2f", got, tt.discount)
+			}
+		})
+	}
+}
+
+func TestAppliesTo(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+	document := Document{
+		Category:       CategoryEngineering,
+		SourceLanguage: "en",
+		TargetLanguage: "id",
+		Quote:          Quote{Amount: 80, Discount: 20},
+	}
+
+	tests := []struct {
+		name   string
+		coupon Coupon
+		err    error
+	}{
+		{"no restrictions", Coupon{Active: true, Kind: CouponPercent, Value: 10}, nil},
+		{"inactive", Coupon{Kind: CouponPercent, Value: 10}, ErrCouponNotValid},
+		{"not started", Coupon{Active: true, Kind: CouponPercent, Value: 10, ValidFrom: &after}, ErrCouponNotValid},
+		{"started", Coupon{Active: true, Kind: CouponPercent, Value: 10, ValidFrom: &now}, nil},
+		{"expired", Coupon{Active: true, Kind: CouponPercent, Value: 10, ValidUntil: &before}, ErrCouponNotValid},
+		{"ends now", Coupon{Active: true, Kind: CouponPercent, Value: 10, ValidUntil: &now}, ErrCouponNotValid},
+		{"within the window", Coupon{Active: true, Kind: CouponPercent, Value: 10, ValidFrom: &before, ValidUntil: &after}, nil},
+		{"matching category", Coupon{Active: true, Kind: CouponPercent, Value: 10, Category: CategoryEngineering}, nil},
+		{"other category", Coupon{Active: true, Kind: CouponPercent, Value: 10, Category: CategoryGeneral}, ErrCouponNotApplicable},
+		{"matching languages", Coupon{Active: true, Kind: CouponPercent, Value: 10, SourceLanguage: "en", TargetLanguage: "id"}, nil},
+		{"other source language", Coupon{Active: true, Kind: CouponPercent, Value: 10, SourceLanguage: "fr"}, ErrCouponNotApplicable},
+		{"other target language", Coupon{Active: true, Kind: CouponPercent, Value: 10, TargetLanguage: "ja"}, ErrCouponNotApplicable},
+		// The quoted price before the earlier discount is 100
+		{"fixed below the price", Coupon{Active: true, Kind: CouponFixed, Value: 99}, nil},
+		{"fixed worth the price", Coupon{Active: true, Kind: CouponFixed, Value: 100}, ErrCouponTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.coupon.AppliesTo(&document, now); !errors.Is(err, tt.err) {
+				t.Fatalf("AppliesTo returned %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestApplyAndRemoveCoupon(t *testing.T) {
+	d := Document{Quote: Quote{Amount: 200}}
+
+	if discount := d.ApplyCoupon(&Coupon{Code: "TEN", Kind: CouponPercent, Value: 10}); discount != 20 {
+		t.Fatalf("discount %.2f", discount)
+	}
+	// A second coupon replaces the first rather than stacking on it
+	if discount := d.ApplyCoupon(&Coupon{Code: "FIVE", Kind: CouponFixed, Value: 5}); discount != 5 {
+		t.Fatalf("discount %.2f", discount)
+	}
+	if d.Quote.Amount != 195 || d.Quote.CouponCode != "FIVE" || d.Quote.Price() != 200 {
+		t.Fatalf("quote %+v", d.Quote)
+	}
+
+	d.RemoveCoupon()
+	if d.Quote.Amount != 200 || d.Quote.Discount != 0 || d.Quote.CouponCode != "" {
+		t.Fatalf("quote %+v after removing the coupon", d.Quote)
+	}
+}
+
+func TestNormalizeCouponCode(t *testing.T) {
+	if got := NormalizeCouponCode("  welcome10 "); got != "WELCOME10" {
+		t.Fatalf("normalized to %q", got)
+	}
+}
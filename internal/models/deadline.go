@@ -0,0 +1,86 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DefaultWordsPerDay is the throughput assumed before a translator is known
+	DefaultWordsPerDay = 2000
+	// wordsPerPage estimates the length of documents whose words couldn't be counted
+	wordsPerPage = 250
+)
+
+// How far a late document has been escalated, each level is notified once
+const (
+	EscalationNone        = 0
+	EscalationAtRisk      = 1 // Due within a day, or estimated to finish after the due date
+	EscalationOverdue     = 2
+	EscalationLongOverdue = 3 // More than a day past the due date
+)
+
+// EstimateDelivery predicts when a translation started at start will be done
+func EstimateDelivery(start time.Time, wordCount, numberOfPages, wordsPerDay int) time.Time {
+	if wordsPerDay <= 0 {
+		wordsPerDay = DefaultWordsPerDay
+	}
+	words := wordCount
+	if words == 0 {
+		words = numberOfPages * wordsPerPage
+	}
+
+	days := int(math.Ceil(float64(words) / float64(wordsPerDay)))
+	if days < 1 {
+		days = 1
+	}
+	return start.AddDate(0, 0, days)
+}
+
+// EstimateFrom sets the estimated delivery of a document whose work starts at start. The due
+// date is left as it was promised.
+func (d *Document) EstimateFrom(start time.Time, wordsPerDay int) {
+	estimate := EstimateDelivery(start, d.WordCount, d.NumberOfPages, wordsPerDay)
+	d.EstimatedDelivery = &estimate
+}
+
+// ScheduleDelivery sets the estimated delivery and the due date of a document counted from start,
+// its upload and then its payment. A deadline the customer asked for wins over the turnaround
+// of the urgency tier.
+func (d *Document) ScheduleDelivery(start time.Time, wordsPerDay, turnaroundDays int) {
+	d.EstimateFrom(start, wordsPerDay)
+	estimate := *d.EstimatedDelivery
+
+	switch {
+	case d.RequestedDeadline != nil:
+		due := *d.RequestedDeadline
+		d.DueAt = &due
+	case turnaroundDays > 0:
+		due := start.AddDate(0, 0, turnaroundDays)
+		d.DueAt = &due
+	default:
+		d.DueAt = &estimate
+	}
+
+	// The due date may have moved, escalations start over
+	d.EscalationLevel = EscalationNone
+}
+
+// EscalationAt returns how late the document is at time now
+func (d *Document) EscalationAt(now time.Time) int {
+	if d.DueAt == nil {
+		return EscalationNone
+	}
+
+	switch {
+	case now.After(d.DueAt.Add(24 * time.Hour)):
+		return EscalationLongOverdue
+	case now.After(*d.DueAt):
+		return EscalationOverdue
+	case now.Add(24 * time.Hour).After(*d.DueAt),
+		d.EstimatedDelivery != nil && d.EstimatedDelivery.After(*d.DueAt):
+		return EscalationAtRisk
+	default:
+		return EscalationNone
+	}
+}
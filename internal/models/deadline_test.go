@@ -0,0 +1,122 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateDelivery(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		words       int
+		pages       int
+		wordsPerDay int
+		days        int
+	}{
+		{"counted words", 4500, 0, 2000, 3},
+		{"exactly a day", 2000, 0, 2000, 1},
+		{"pages when words are unknown", 0, 10, 1000, 3},
+		{"at least a day", 10, 0, 2000, 1},
+		{"default throughput", 4000, 0, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateDelivery(start, tt.words, tt.pages, tt.wordsPerDay)
+			if want := start.AddDate(0, 0, tt.days); !got.Equal(want) {
+				t.Fatalf("estimated %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestScheduleDelivery(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	requested := start.AddDate(0, 0, 10)
+
+	tests := []struct {
+		name       string
+		deadline   *time.Time
+		turnaround int
+		due        time.Time
+	}{
+		{"requested deadline", &requested, 2, requested},
+		{"urgency turnaround", nil, 2, start.AddDate(0, 0, 2)},
+		{"no promise, the estimate", nil, 0, start.AddDate(0, 0, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Document{WordCount: 5000, RequestedDeadline: tt.deadline, EscalationLevel: EscalationOverdue}
+			d.ScheduleDelivery(start, 2000, tt.turnaround)
+
+			if !d.EstimatedDelivery.Equal(start.AddDate(0, 0, 3)) {
+				t.Fatalf("estimated %v", d.EstimatedDelivery)
+			}
+			if !d.DueAt.Equal(tt.due) {
+				t.Fatalf("due %v, want %v", d.DueAt, tt.due)
+			}
+			if d.EscalationLevel != EscalationNone {
+				t.Fatalf("escalation level %d was kept", d.EscalationLevel)
+			}
+			if tt.deadline != nil && d.DueAt == tt.deadline {
+				t.Fatal("the due date shares the requested deadline")
+			}
+		})
+	}
+}
+
+func TestEstimateFromKeepsDueDate(t *testing.T) {
+	paid := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	d := Document{WordCount: 4000}
+	d.ScheduleDelivery(paid, DefaultWordsPerDay, 3)
+	d.EscalationLevel = EscalationAtRisk
+
+	// A slow translator accepts two days later
+	accepted := paid.AddDate(0, 0, 2)
+	d.EstimateFrom(accepted, 1000)
+
+	if !d.DueAt.Equal(paid.AddDate(0, 0, 3)) {
+		t.Fatalf("due date moved to %v", d.DueAt)
+	}
+	if !d.EstimatedDelivery.Equal(accepted.AddDate(0, 0, 4)) {
+		t.Fatalf("estimated %v", d.EstimatedDelivery)
+	}
+	if d.EscalationLevel != EscalationAtRisk {
+		t.Fatalf("escalation level reset to %d", d.EscalationLevel)
+	}
+	if d.EscalationAt(accepted) != EscalationAtRisk {
+		t.Fatal("an estimate past the due date isn't at risk")
+	}
+}
+
+func TestEscalationAt(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) *time.Time {
+		t := now.Add(offset)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		due      *time.Time
+		estimate *time.Time
+		level    int
+	}{
+		{"no due date", nil, nil, EscalationNone},
+		{"due in two days", at(48 * time.Hour), at(24 * time.Hour), EscalationNone},
+		{"due within a day", at(23 * time.Hour), at(time.Hour), EscalationAtRisk},
+		{"estimated after the due date", at(72 * time.Hour), at(96 * time.Hour), EscalationAtRisk},
+		{"just overdue", at(-time.Hour), nil, EscalationOverdue},
+		{"a day overdue", at(-24 * time.Hour), nil, EscalationOverdue},
+		{"more than a day overdue", at(-25 * time.Hour), nil, EscalationLongOverdue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Document{DueAt: tt.due, EstimatedDelivery: tt.estimate}
+			if got := d.EscalationAt(now); got != tt.level {
+				t.Fatalf("level %d, want %d", got, tt.level)
+			}
+		})
+	}
+}
@@ -16,25 +16,35 @@ const (
 type Document struct {
 	gorm.Model
 	UserID                   uint
-	TranslatorID             uint // ID of the assigned translator
+	TranslatorID             uint          // ID of the assigned translator
+	State                    DocumentState `gorm:"index"` // Lifecycle state, see lifecycle.go
 	Title                    string
 	Description              string
 	Category                 string // Allowed values: "general", "engineering", "social sciences"
-	FileContent              []byte
+	FileKey                  string // Blob store key of the uploaded document
 	FileName                 string
 	SourceLanguage           string
 	TargetLanguage           string
 	NumberOfPages            int
-	TranslatedFileContent    []byte // Path to the translated document
-	TranslatedFileName       string // Path to the translated document
-	Status                   string // e.g., "Pending", "In Progress", "Completed"
+	WordCount                int    // Counted from the uploaded file, 0 when text could not be extracted
+	TranslatedFileKey        string // Blob store key of the translated document
+	TranslatedFileName       string
+	Status                   string // Legacy, kept in sync with State: "Pending", "Translating", "Finished"
 	PaymentConfirmed         bool   // Field to check if the payment is confirmed
 	ApprovalStatus           string // e.g., "Pending", "Approved", "Rejected"
 	TranslatedApprovalStatus string // e.g., "Pending", "Approved", "Rejected"
 	TranslatorApprovalStatus string // e.g., "Pending", "Accepted", "Declined"
-	PaymentReceiptContent    []byte
+	PaymentReceiptKey        string // Blob store key of the payment receipt
 	PaymentReceiptFileName   string
-	AssignmentTime           time.Time // Time when the document was assigned to the translator
+	AssignmentTime           time.Time  // Time when the document was assigned to the translator
+	Urgency                  string     // Code of the UrgencyTier picked on upload
+	Quote                    Quote      `gorm:"embedded;embeddedPrefix:quote_"`
+	RequestedDeadline        *time.Time // Deadline the customer asked for on upload
+	EstimatedDelivery        *time.Time // Predicted from the word count and the translator's throughput
+	DueAt                    *time.Time `gorm:"index"` // Date the delivery is tracked against, see ScheduleDelivery
+	EscalationLevel          int        // Highest lateness already notified, see the Escalation* constants
+	DeliveredAt              *time.Time // Last time a translation was delivered, starts the revision window
+	RevisionRounds           int        // Revisions the customer asked for so far
 }
 
 func (d *Document) Validate() error {
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EventApproved            = "approved"
+	EventRejected            = "rejected"
+	EventQuoteAccepted       = "quote_accepted"
+	EventReceiptUploaded     = "receipt_uploaded"
+	EventPaymentApproved     = "payment_approved"
+	EventPaymentReceived     = "payment_received" // Confirmed by the payment gateway
+	EventAssigned            = "assigned"
+	EventAssignmentAccepted  = "assignment_accepted"
+	EventAssignmentDeclined  = "assignment_declined"
+	EventAssignmentExpired   = "assignment_expired"
+	EventTranslationUploaded = "translation_uploaded"
+	EventTranslationApproved = "translation_approved"
+	EventTranslationRejected = "translation_rejected"
+	EventRevisionRequested   = "revision_requested"
+	EventCancelled           = "cancelled"
+	EventRefunded            = "refunded"
+	EventCouponApplied       = "coupon_applied"
+	EventCouponRemoved       = "coupon_removed"
+)
+
+// RoleSystem marks events recorded by background jobs rather than a user
+const RoleSystem = "system"
+
+var ErrEventImmutable = errors.New("document events are append-only")
+
+// DocumentEvent is an append-only audit record of something that happened to a document
+type DocumentEvent struct {
+	ID           uint          `gorm:"primarykey"`
+	CreatedAt    time.Time     `gorm:"index"`
+	DocumentID   uint          `gorm:"not null;index"`
+	ActorID      uint          `gorm:"index"` // 0 when the system acted, e.g. the scheduler
+	ActorRole    string        `gorm:"not null"`
+	Action       string        `gorm:"not null;index"`
+	FromState    DocumentState // Empty for events that don't change the state
+	ToState      DocumentState
+	TranslatorID uint   // Translator assigned to the document when the event happened
+	ReasonCode   string `gorm:"index"` // One of ReasonCodes for rejections and declines
+	Reason       string `gorm:"type:text"`
+}
+
+func (e *DocumentEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrEventImmutable
+}
+
+func (e *DocumentEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrEventImmutable
+}
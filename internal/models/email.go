@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+// EmailOutbox is a notification email waiting to be delivered or kept as a record once sent
+type EmailOutbox struct {
+	gorm.Model
+	NotificationID uint
+	UserID         uint   `gorm:"not null;index"`
+	To             string `gorm:"not null"`
+	Subject        string `gorm:"not null"`
+	TextBody       string `gorm:"type:text"`
+	HTMLBody       string `gorm:"type:text"`
+	Status         string `gorm:"not null;index"`
+	Attempts       int
+	NextAttemptAt  time.Time `gorm:"index"`
+	LastError      string    `gorm:"type:text"`
+	SentAt         *time.Time
+}
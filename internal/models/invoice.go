@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of invoice, each numbered in its own series
+const (
+	InvoiceKindInvoice = "invoice" // Issued when the customer accepts the quote
+	InvoiceKindReceipt = "receipt" // Issued once the payment is confirmed
+)
+
+// invoicePrefixes start the numbers of each kind, e.g. INV-2026-00042
+var invoicePrefixes = map[string]string{
+	InvoiceKindInvoice: "INV",
+	InvoiceKindReceipt: "RCP",
+}
+
+// Invoice is an issued invoice or payment receipt. Everything printed on it is copied
+// from the document and customer when it is issued, so later edits don't change it.
+type Invoice struct {
+	gorm.Model
+	Number         string `gorm:"not null;uniqueIndex"`
+	Kind           string `gorm:"not null;uniqueIndex:idx_invoice_document_kind"`
+	DocumentID     uint   `gorm:"not null;uniqueIndex:idx_invoice_document_kind"`
+	UserID         uint   `gorm:"not null;index"`
+	CustomerName   string
+	CustomerEmail  string
+	DocumentTitle  string
+	SourceLanguage string
+	TargetLanguage string
+	Quantity       int    // Words or pages billed, 1 for a flat price
+	Unit           string // "word", "page" or "flat"
+	UnitPrice      float64
+	Discount       float64 // Taken off the price by a coupon
+	CouponCode     string
+	Subtotal       float64 // Total without tax
+	TaxRate        float64 // Percent
+	Tax            float64
+	Total          float64
+	Currency       string
+	IssuedAt       time.Time `gorm:"not null;index"`
+	PaidAt         *time.Time
+}
+
+// InvoiceSequence holds the last number used in a series, locked while a number is taken
+type InvoiceSequence struct {
+	Series string `gorm:"primaryKey"` // e.g. "INV-2026"
+	Last   int    `gorm:"not null"`
+}
+
+// InvoiceSeries returns the series an invoice of this kind issued at t is numbered in
+func InvoiceSeries(kind string, t time.Time) string {
+	return fmt.Sprintf("%s-%d", invoicePrefixes[kind], t.Year())
+}
+
+// InvoiceNumber formats the n-th number of a series
+func InvoiceNumber(series string, n int) string {
+	return fmt.Sprintf("%s-%05d", series, n)
+}
+
+// WithoutTax returns the part of a tax inclusive amount that isn't the taxRate percent of tax
+func WithoutTax(amount, taxRate float64) float64 {
+	return RoundMoney(amount / (1 + taxRate/100))
+}
+
+// NewInvoice prices an invoice from the accepted quote of a document. Quoted prices
+// include tax, which is shown separately at taxRate percent of the discounted total.
+func NewInvoice(kind string, d *Document, customer *User, taxRate float64, currency string) Invoice {
+	invoice := Invoice{
+		Kind:           kind,
+		DocumentID:     d.ID,
+		UserID:         d.UserID,
+		CustomerName:   customer.Username,
+		CustomerEmail:  customer.Email,
+		DocumentTitle:  d.Title,
+		SourceLanguage: d.SourceLanguage,
+		TargetLanguage: d.TargetLanguage,
+		Discount:       d.Quote.Discount,
+		CouponCode:     d.Quote.CouponCode,
+		Total:          d.Quote.Amount,
+		TaxRate:        taxRate,
+		Currency:       currency,
+	}
+
+	multiplier := d.Quote.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	price := d.Quote.Price()
+	switch {
+	case !d.Quote.Manual && d.WordCount > 0 && d.Quote.PricePerWord > 0 && price > d.Quote.MinimumCharge:
+		invoice.Quantity = d.WordCount
+		invoice.Unit = "word"
+		invoice.UnitPrice = d.Quote.PricePerWord * multiplier
+	case !d.Quote.Manual && d.NumberOfPages > 0 && d.Quote.PricePerPage > 0 && price > d.Quote.MinimumCharge:
+		invoice.Quantity = d.NumberOfPages
+		invoice.Unit = "page"
+		invoice.UnitPrice = d.Quote.PricePerPage * multiplier
+	default:
+		// Manual prices and minimum charges aren't per unit
+		invoice.Quantity = 1
+		invoice.Unit = "flat"
+		invoice.UnitPrice = price
+	}
+
+	invoice.Subtotal = WithoutTax(invoice.Total, taxRate)
+	invoice.Tax = RoundMoney(invoice.Total - invoice.Subtotal)
+	return invoice
+}
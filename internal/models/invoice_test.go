@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewInvoice(t *testing.T) {
+	tests := []struct {
+		name      string
+		document  Document
+		quantity  int
+		unit      string
+		unitPrice float64
+		subtotal  float64
+		tax       float64
+	}{
+		{
+			"per word",
+			Document{WordCount: 1000, NumberOfPages: 4, Quote: Quote{PricePerWord: 0.111, Multiplier: 1, Amount: 111}},
+			1000, "word", 0.111, 100, 11,
+		},
+		{
+			"per word with urgency",
+			Document{WordCount: 1000, Quote: Quote{PricePerWord: 0.1, Multiplier: 1.5, Amount: 150}},
+			1000, "word", 0.15, 135.14, 14.86,
+		},
+		{
+			"per page when words are unknown",
+			Document{NumberOfPages: 4, Quote: Quote{PricePerWord: 0.1, PricePerPage: 27.75, Amount: 111}},
+			4, "page", 27.75, 100, 11,
+		},
+		{
+			"minimum charge is flat",
+			Document{WordCount: 10, Quote: Quote{PricePerWord: 0.1, MinimumCharge: 55.5, Multiplier: 1, Amount: 55.5}},
+			1, "flat", 55.5, 50, 5.5,
+		},
+		{
+			"manual price is flat",
+			Document{WordCount: 1000, Quote: Quote{PricePerWord: 0.1, Manual: true, Amount: 222}},
+			1, "flat", 222, 200, 22,
+		},
+		{
+			"discount comes off the taxed total",
+			Document{WordCount: 1000, Quote: Quote{PricePerWord: 0.222, Multiplier: 1, Amount: 111, Discount: 111, CouponCode: "HALF"}},
+			1000, "word", 0.222, 100, 11,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := User{Username: "Ayu", Email: "ayu@example.test"}
+			invoice := NewInvoice(InvoiceKindInvoice, &tt.document, &customer, 11, "IDR")
+
+			if invoice.Quantity != tt.quantity || invoice.Unit != tt.unit || RoundMoney(invoice.UnitPrice*1000) != RoundMoney(tt.unitPrice*1000) {
+				t.Fatalf("billed %d %s at %v, want %d %s at %v", invoice.Quantity, invoice.Unit, invoice.UnitPrice, tt.quantity, tt.unit, tt.unitPrice)
+			}
+			if invoice.Total != tt.document.Quote.Amount || invoice.Subtotal != tt.subtotal || invoice.Tax != tt.tax {
+				t.Fatalf("total %.2f split into %.2f and %.2f tax, want %.2f and %.2f", invoice.Total, invoice.Subtotal, invoice.Tax, tt.subtotal, tt.tax)
+			}
+			if RoundMoney(invoice.Subtotal+invoice.Tax) != invoice.Total {
+				t.Fatal("subtotal and tax don't add up to the total")
+			}
+			if invoice.Discount != tt.document.Quote.Discount || invoice.CouponCode != tt.document.Quote.CouponCode {
+				t.Fatalf("discount %.2f with %q", invoice.Discount, invoice.CouponCode)
+			}
+			if invoice.TaxRate != 11 || invoice.CustomerName != "Ayu" || invoice.Currency != "IDR" {
+				t.Fatalf("invoice %+v", invoice)
+			}
+		})
+	}
+}
+
+func TestNewInvoiceWithoutTax(t *testing.T) {
+	d := Document{Quote: Quote{Manual: true, Amount: 100}}
+	invoice := NewInvoice(InvoiceKindReceipt, &d, &User{}, 0, "IDR")
+	if invoice.Subtotal != 100 || invoice.Tax != 0 {
+		t.Fatalf("untaxed total split into %.2f and %.2f tax", invoice.Subtotal, invoice.Tax)
+	}
+}
+
+func TestInvoiceNumber(t *testing.T) {
+	issued := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	if got := InvoiceNumber(InvoiceSeries(InvoiceKindInvoice, issued), 42); got != "INV-2026-00042" {
+		t.Fatalf("invoice number %q", got)
+	}
+	if got := InvoiceNumber(InvoiceSeries(InvoiceKindReceipt, issued), 123456); got != "RCP-2026-123456" {
+		t.Fatalf("receipt number %q", got)
+	}
+}
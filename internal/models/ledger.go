@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How a translator is paid for a delivered document
+const (
+	PayRevenueShare = "revenue_share" // PayRate percent of the price the customer paid
+	PayPerWord      = "per_word"      // PayRate per word of the source document
+)
+
+const (
+	// DefaultPayRate is the revenue share of translators who have no rate set
+	DefaultPayRate = 70
+
+	// Kinds of ledger entry
+	LedgerTranslation  = "translation"  // Credited when the translation is delivered
+	LedgerCancellation = "cancellation" // Compensation for work on a cancelled document
+)
+
+// LedgerEntry is money owed to a translator. Entries are paid out in a Payout and never changed otherwise.
+type LedgerEntry struct {
+	gorm.Model
+	TranslatorID uint   `gorm:"not null;index;uniqueIndex:idx_ledger_document"`
+	DocumentID   uint   `gorm:"not null;uniqueIndex:idx_ledger_document"`
+	Kind         string `gorm:"not null;uniqueIndex:idx_ledger_document"` // A document is credited once per kind
+	Description  string
+	Amount       float64 `gorm:"not null"`
+	PayoutID     *uint   `gorm:"index"` // Set once the entry has been paid out
+}
+
+// Payout is a batch of ledger entries paid to translators by bank transfer
+type Payout struct {
+	gorm.Model
+	CreatedByID uint      `gorm:"not null"`
+	Until       time.Time // Entries created before this time were included
+	Total       float64
+	EntryCount  int
+}
+
+// BankAccount is where a translator's payouts are transferred to
+type BankAccount struct {
+	gorm.Model
+	UserID        uint   `gorm:"not null;uniqueIndex"`
+	BankName      string `gorm:"not null"`
+	AccountNumber string `gorm:"not null"`
+	AccountName   string `gorm:"not null"`
+}
+
+func (b *BankAccount) Validate() error {
+	if b.BankName == "" || b.AccountNumber == "" || b.AccountName == "" {
+		return errors.New("bank name, account number and account name are required")
+	}
+	return nil
+}
+
+// ValidatePayRate checks a pay rate set for a translator
+func ValidatePayRate(rateType string, rate float64) error {
+	switch rateType {
+	case PayRevenueShare:
+		if rate < 0 || rate > 100 {
+			return errors.New("a revenue share must be between 0 and 100 percent")
+		}
+	case PayPerWord:
+		if rate < 0 {
+			return errors.New("a rate per word cannot be negative")
+		}
+	default:
+		return errors.New("pay rate type must be 'revenue_share' or 'per_word'")
+	}
+	return nil
+}
+
+// Earning is what the translator earns for delivering the document. A revenue share is taken
+// of the price without the taxRate percent of tax it includes, the tax isn't revenue.
+func (u *User) Earning(d *Document, taxRate float64) float64 {
+	if u.PayRateType == PayPerWord {
+		words := d.WordCount
+		if words == 0 {
+			words = d.NumberOfPages * wordsPerPage
+		}
+		return RoundMoney(float64(words) * u.PayRate)
+	}
+	return RoundMoney(WithoutTax(d.Quote.Amount, taxRate) * u.PayRate / 100)
+}
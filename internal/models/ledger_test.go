@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestEarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		rateType string
+		rate     float64
+		document Document
+		taxRate  float64
+		earning  float64
+	}{
+		{"share without tax", PayRevenueShare, 70, Document{Quote: Quote{Amount: 111}}, 11, 70},
+		{"share, no tax", PayRevenueShare, 70, Document{Quote: Quote{Amount: 100}}, 0, 70},
+		{"share of the discounted price", PayRevenueShare, 50, Document{Quote: Quote{Amount: 55.5, Discount: 55.5}}, 11, 25},
+		{"share rounded to cents", PayRevenueShare, 33, Document{Quote: Quote{Amount: 10}}, 0, 3.3},
+		{"per word ignores tax", PayPerWord, 0.05, Document{WordCount: 1000, Quote: Quote{Amount: 111}}, 11, 50},
+		{"per word by pages", PayPerWord, 0.1, Document{NumberOfPages: 2}, 11, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := User{PayRateType: tt.rateType, PayRate: tt.rate}
+			if got := translator.Earning(&tt.document, tt.taxRate); got != tt.earning {
+				t.Fatalf("earned %.2f, want %.2f", got, tt.earning)
+			}
+		})
+	}
+}
+
+func TestWithoutTax(t *testing.T) {
+	if got := WithoutTax(111, 11); got != 100 {
+		t.Fatalf("WithoutTax(111, 11) = %.2f", got)
+	}
+	if got := WithoutTax(100, 0); got != 100 {
+		t.Fatalf("WithoutTax(100, 0) = %.2f", got)
+	}
+	if got := WithoutTax(10, 11); got != 9.01 {
+		t.Fatalf("WithoutTax(10, 11) = %.2f", got)
+	}
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DocumentState is the single source of truth for where a document is in its lifecycle
+type DocumentState string
+
+const (
+	StateSubmitted   DocumentState = "Submitted"
+	StateApproved    DocumentState = "Approved"
+	StateQuoted      DocumentState = "Quoted"
+	StatePaid        DocumentState = "Paid"
+	StateAssigned    DocumentState = "Assigned"
+	StateTranslating DocumentState = "Translating"
+	StateInReview    DocumentState = "InReview"
+	StateDelivered   DocumentState = "Delivered"
+	StateRejected    DocumentState = "Rejected"
+	StateCancelled   DocumentState = "Cancelled"
+)
+
+var ErrInvalidTransition = errors.New("invalid document state transition")
+
+// documentTransitions lists every state a document may move to from a given state
+var documentTransitions = map[DocumentState][]DocumentState{
+	StateSubmitted: {StateApproved, StateRejected, StateCancelled},
+	StateApproved:  {StateQuoted, StateCancelled},
+	StateQuoted:    {StatePaid, StateCancelled},
+	StatePaid:      {StateAssigned, StateCancelled},
+	// Assigned goes back to Paid when the translator declines or lets the assignment expire
+	StateAssigned:    {StateTranslating, StatePaid, StateCancelled},
+	StateTranslating: {StateInReview, StateCancelled},
+	// InReview goes back to Translating when the admin rejects the translation
+	StateInReview: {StateDelivered, StateTranslating, StateCancelled},
+	// Delivered goes back to Translating when the customer asks for a revision
+	StateDelivered: {StateTranslating},
+	StateRejected:  {},
+	StateCancelled: {},
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to to
+func (s DocumentState) CanTransitionTo(to DocumentState) bool {
+	for _, next := range documentTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo moves the document to a new state, refusing transitions the lifecycle doesn't allow
+func (d *Document) TransitionTo(to DocumentState) error {
+	if !d.State.CanTransitionTo(to) {
+		return fmt.Errorf("%w: cannot move document from %s to %s", ErrInvalidTransition, d.State, to)
+	}
+
+	from := d.State
+	d.State = to
+	d.syncLegacyStatus(from)
+	return nil
+}
+
+// syncLegacyStatus keeps the older status columns that existing clients still read in step with State
+func (d *Document) syncLegacyStatus(from DocumentState) {
+	switch d.State {
+	case StateSubmitted:
+		d.Status = "Pending"
+		d.ApprovalStatus = "Pending"
+	case StateApproved, StateQuoted:
+		d.ApprovalStatus = "Approved"
+	case StateRejected:
+		d.ApprovalStatus = "Rejected"
+	case StatePaid:
+		d.PaymentConfirmed = true
+		if from == StateAssigned {
+			// The translator declined or didn't answer, the document goes back to the unassigned pool
+			d.TranslatorApprovalStatus = "Declined"
+			d.TranslatorID = 0
+		}
+	case StateAssigned:
+		d.TranslatorApprovalStatus = "Pending"
+	case StateTranslating:
+		d.Status = "Translating"
+		switch from {
+		case StateInReview:
+			d.TranslatedApprovalStatus = "Rejected"
+		case StateDelivered:
+			d.TranslatedApprovalStatus = "RevisionRequested"
+		default:
+			d.TranslatorApprovalStatus = "Accepted"
+		}
+	case StateInReview:
+		d.TranslatedApprovalStatus = "Pending"
+	case StateDelivered:
+		d.TranslatedApprovalStatus = "Approved"
+		d.Status = "Finished"
+	case StateCancelled:
+		d.Status = "Cancelled"
+	}
+}
+
+// LegacyState derives the lifecycle state of a document created before State existed
+func (d *Document) LegacyState() DocumentState {
+	switch {
+	case d.Status == "Finished" || d.TranslatedApprovalStatus == "Approved":
+		return StateDelivered
+	case d.ApprovalStatus == "Rejected":
+		return StateRejected
+	case d.TranslatedFileKey != "" && d.TranslatedApprovalStatus == "Pending":
+		return StateInReview
+	case d.Status == "Translating" || d.TranslatorApprovalStatus == "Accepted":
+		return StateTranslating
+	case d.TranslatorID != 0 && d.TranslatorApprovalStatus == "Pending":
+		return StateAssigned
+	case d.PaymentConfirmed:
+		return StatePaid
+	case d.ApprovalStatus == "Approved":
+		// There was no separate quote step, an approved document was already priced
+		return StateQuoted
+	default:
+		return StateSubmitted
+	}
+}
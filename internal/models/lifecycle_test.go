@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+var allStates = []DocumentState{
+	StateSubmitted, StateApproved, StateQuoted, StatePaid, StateAssigned,
+	StateTranslating, StateInReview, StateDelivered, StateRejected, StateCancelled,
+}
+
+func TestDocumentTransitions(t *testing.T) {
+	allowed := map[DocumentState][]DocumentState{
+		StateSubmitted:   {StateApproved, StateRejected, StateCancelled},
+		StateApproved:    {StateQuoted, StateCancelled},
+		StateQuoted:      {StatePaid, StateCancelled},
+		StatePaid:        {StateAssigned, StateCancelled},
+		StateAssigned:    {StateTranslating, StatePaid, StateCancelled},
+		StateTranslating: {StateInReview, StateCancelled},
+		StateInReview:    {StateDelivered, StateTranslating, StateCancelled},
+		StateDelivered:   {StateTranslating},
+		StateRejected:    {},
+		StateCancelled:   {},
+	}
+
+	for _, from := range allStates {
+		for _, to := range allStates {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s allowed = %v, want %v", from, to, got, want)
+			}
+
+			d := Document{State: from}
+			err := d.TransitionTo(to)
+			switch {
+			case want && err != nil:
+				t.Errorf("%s -> %s: %v", from, to, err)
+			case want && d.State != to:
+				t.Errorf("%s -> %s left the document in %s", from, to, d.State)
+			case !want && !errors.Is(err, ErrInvalidTransition):
+				t.Errorf("%s -> %s error = %v, want ErrInvalidTransition", from, to, err)
+			case !want && d.State != from:
+				t.Errorf("refused %s -> %s still moved the document to %s", from, to, d.State)
+			}
+		}
+	}
+}
+
+func TestTransitionSyncsLegacyStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		document Document
+		to       DocumentState
+		want     Document
+	}{
+		{"approved", Document{State: StateSubmitted}, StateApproved,
+			Document{State: StateApproved, ApprovalStatus: "Approved"}},
+		{"rejected", Document{State: StateSubmitted}, StateRejected,
+			Document{State: StateRejected, ApprovalStatus: "Rejected"}},
+		{"paid", Document{State: StateQuoted, ApprovalStatus: "Approved"}, StatePaid,
+			Document{State: StatePaid, ApprovalStatus: "Approved", PaymentConfirmed: true}},
+		{"assigned", Document{State: StatePaid, PaymentConfirmed: true, TranslatorID: 7}, StateAssigned,
+			Document{State: StateAssigned, PaymentConfirmed: true, TranslatorID: 7, TranslatorApprovalStatus: "Pending"}},
+		{"declined", Document{State: StateAssigned, PaymentConfirmed: true, TranslatorID: 7, TranslatorApprovalStatus: "Pending"}, StatePaid,
+			Document{State: StatePaid, PaymentConfirmed: true, TranslatorApprovalStatus: "Declined"}},
+		{"accepted", Document{State: StateAssigned, TranslatorID: 7}, StateTranslating,
+			Document{State: StateTranslating, TranslatorID: 7, Status: "Translating", TranslatorApprovalStatus: "Accepted"}},
+		{"uploaded", Document{State: StateTranslating}, StateInReview,
+			Document{State: StateInReview, TranslatedApprovalStatus: "Pending"}},
+		{"translation rejected", Document{State: StateInReview}, StateTranslating,
+			Document{State: StateTranslating, Status: "Translating", TranslatedApprovalStatus: "Rejected"}},
+		{"delivered", Document{State: StateInReview}, StateDelivered,
+			Document{State: StateDelivered, Status: "Finished", TranslatedApprovalStatus: "Approved"}},
+		{"revision requested", Document{State: StateDelivered, Status: "Finished"}, StateTranslating,
+			Document{State: StateTranslating, Status: "Translating", TranslatedApprovalStatus: "RevisionRequested"}},
+		{"cancelled", Document{State: StatePaid}, StateCancelled,
+			Document{State: StateCancelled, Status: "Cancelled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.document
+			if err := d.TransitionTo(tt.to); err != nil {
+				t.Fatal(err)
+			}
+			if d.State != tt.want.State || d.Status != tt.want.Status || d.ApprovalStatus != tt.want.ApprovalStatus ||
+				d.PaymentConfirmed != tt.want.PaymentConfirmed || d.TranslatorID != tt.want.TranslatorID ||
+				d.TranslatorApprovalStatus != tt.want.TranslatorApprovalStatus || d.TranslatedApprovalStatus != tt.want.TranslatedApprovalStatus {
+				t.Fatalf("got state %s, status %q, approval %q, paid %v, translator %d (%q), translation %q",
+					d.State, d.Status, d.ApprovalStatus, d.PaymentConfirmed, d.TranslatorID, d.TranslatorApprovalStatus, d.TranslatedApprovalStatus)
+			}
+		})
+	}
+}
+
+func TestLegacyState(t *testing.T) {
+	tests := []struct {
+		document Document
+		want     DocumentState
+	}{
+		{Document{Status: "Pending", ApprovalStatus: "Pending"}, StateSubmitted},
+		// There was no quote step, approved documents were already priced
+		{Document{ApprovalStatus: "Approved"}, StateQuoted},
+		{Document{ApprovalStatus: "Rejected"}, StateRejected},
+		{Document{ApprovalStatus: "Approved", PaymentConfirmed: true}, StatePaid},
+		{Document{PaymentConfirmed: true, TranslatorID: 7, TranslatorApprovalStatus: "Pending"}, StateAssigned},
+		{Document{PaymentConfirmed: true, TranslatorID: 7, TranslatorApprovalStatus: "Declined"}, StatePaid},
+		{Document{TranslatorID: 7, TranslatorApprovalStatus: "Accepted"}, StateTranslating},
+		{Document{Status: "Translating", TranslatedFileKey: "translations/a.docx", TranslatedApprovalStatus: "Pending"}, StateInReview},
+		{Document{Status: "Finished"}, StateDelivered},
+		{Document{TranslatedApprovalStatus: "Approved"}, StateDelivered},
+	}
+	for _, tt := range tests {
+		if got := tt.document.LegacyState(); got != tt.want {
+			t.Errorf("LegacyState of %+v = %s, want %s", tt.document, got, tt.want)
+		}
+	}
+}
@@ -4,10 +4,19 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	NotificationGeneral            = "general"
+	NotificationDocumentApproved   = "document_approved"
+	NotificationDocumentAssigned   = "document_assigned"
+	NotificationDocumentTranslated = "document_translated"
+	NotificationPaymentApproved    = "payment_approved"
+)
+
 type Notification struct {
 	gorm.Model
 	UserID     uint   `gorm:"not null"`
 	DocumentID uint   `gorm:"not null"`
+	Type       string `gorm:"not null;default:'general'"`
 	Message    string `gorm:"not null"`
 	Read       bool   `gorm:"default:false"`
 }
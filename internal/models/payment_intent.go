@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statuses of a PaymentIntent, the same as payments.Status
+const (
+	PaymentPending = "pending"
+	PaymentPaid    = "paid"
+	PaymentFailed  = "failed"
+	PaymentExpired = "expired"
+)
+
+// PaymentIntent is an online payment of a document's quote started with the payment gateway
+type PaymentIntent struct {
+	gorm.Model
+	DocumentID uint    `gorm:"not null;index"`
+	UserID     uint    `gorm:"not null"`
+	Provider   string  `gorm:"not null"`
+	ProviderID string  `gorm:"index"`                // The gateway's ID of the payment
+	Reference  string  `gorm:"not null;uniqueIndex"` // Our reference, the gateway sends it back in webhooks
+	Amount     float64 `gorm:"not null"`
+	Currency   string  `gorm:"not null"`
+	Status     string  `gorm:"not null;default:'pending'"`
+	PaymentURL string
+	ExpiresAt  *time.Time
+	PaidAt     *time.Time
+}
+
+// Reusable reports whether the customer can still pay through this intent instead of starting a new one
+func (p *PaymentIntent) Reusable(amount float64, now time.Time) bool {
+	return p.Status == PaymentPending && p.Amount == amount && (p.ExpiresAt == nil || now.Before(*p.ExpiresAt))
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	UrgencyStandard = "standard"
+	UrgencyExpress  = "express"
+	UrgencyUrgent   = "urgent"
+)
+
+// PricingRule prices documents matching a language pair and category. Empty
+// fields match anything, the most specific matching rule wins.
+type PricingRule struct {
+	gorm.Model
+	SourceLanguage string
+	TargetLanguage string
+	Category       string
+	PricePerWord   float64
+	PricePerPage   float64 // Fallback when the words of a document couldn't be counted
+	MinimumCharge  float64
+	Active         bool
+}
+
+func (r *PricingRule) Validate() error {
+	if r.Category != "" {
+		switch r.Category {
+		case CategoryGeneral, CategoryEngineering, CategorySocialSciences:
+		default:
+			return errors.New("invalid category: must be one of 'general', 'engineering', or 'social sciences'")
+		}
+	}
+	if r.PricePerWord < 0 || r.PricePerPage < 0 || r.MinimumCharge < 0 {
+		return errors.New("prices cannot be negative")
+	}
+	if r.PricePerWord == 0 && r.PricePerPage == 0 {
+		return errors.New("either a price per word or a price per page is required")
+	}
+	return nil
+}
+
+// GlobalPricingRule is the rule for documents no pricing rule matches, priced with the
+// global price per word. Documents whose words couldn't be counted are priced per page
+// at the same estimate of words per page used for deadlines.
+func GlobalPricingRule(settings *Settings) PricingRule {
+	return PricingRule{
+		PricePerWord: settings.PricePerWord,
+		PricePerPage: settings.PricePerWord * wordsPerPage,
+	}
+}
+
+// Specificity ranks how closely the rule targets a document, or -1 if it doesn't apply
+func (r *PricingRule) Specificity(d *Document) int {
+	score := 0
+	for _, field := range []struct {
+		rule, document string
+		weight         int
+	}{
+		{r.SourceLanguage, d.SourceLanguage, 2},
+		{r.TargetLanguage, d.TargetLanguage, 2},
+		{r.Category, d.Category, 1},
+	} {
+		if field.rule == "" {
+			continue
+		}
+		if field.rule != field.document {
+			return -1
+		}
+		score += field.weight
+	}
+	return score
+}
+
+// UrgencyTier is a turnaround option customers pick on upload, priced as a multiplier
+type UrgencyTier struct {
+	gorm.Model
+	Code           string  `gorm:"uniqueIndex;not null"`
+	Name           string  `gorm:"not null"`
+	Multiplier     float64 `gorm:"not null;default:1"`
+	TurnaroundDays int     // Days promised for delivery once the document is paid
+}
+
+func (t *UrgencyTier) Validate() error {
+	if t.Code == "" || t.Name == "" {
+		return errors.New("code and name are required")
+	}
+	if t.Multiplier <= 0 {
+		return errors.New("multiplier must be positive")
+	}
+	if t.TurnaroundDays < 0 {
+		return errors.New("turnaround days cannot be negative")
+	}
+	return nil
+}
+
+// Quote is the price of a document as computed when it was quoted. It is stored
+// on the document so later pricing changes don't alter existing orders.
+type Quote struct {
+	RuleID        uint // 0 for the global price per word or a manual quote
+	Urgency       string
+	PricePerWord  float64
+	PricePerPage  float64
+	Multiplier    float64
+	MinimumCharge float64
+	Amount        float64 // What the customer pays, after any discount
+	Manual        bool
+	QuotedAt      *time.Time
+	Discount      float64 // Taken off by a coupon, see Document.ApplyCoupon
+	CouponCode    string
+	TaxRate       float64 // Percent of tax included in Amount, as it was when quoted
+}
+
+// Price is the quoted price before any discount
+func (q Quote) Price() float64 {
+	return RoundMoney(q.Amount + q.Discount)
+}
+
+// NewQuote prices a document with a rule and urgency multiplier. It fails when the
+// rule has no price for what is known about the document.
+func NewQuote(d *Document, rule PricingRule, urgency string, multiplier float64) (Quote, error) {
+	var amount float64
+	switch {
+	case d.WordCount > 0 && rule.PricePerWord > 0:
+		amount = float64(d.WordCount) * rule.PricePerWord
+	case d.NumberOfPages > 0 && rule.PricePerPage > 0:
+		amount = float64(d.NumberOfPages) * rule.PricePerPage
+	default:
+		return Quote{}, errors.New("no price applies to this document")
+	}
+
+	amount *= multiplier
+	if amount < rule.MinimumCharge {
+		amount = rule.MinimumCharge
+	}
+
+	now := time.Now()
+	return Quote{
+		RuleID:        rule.ID,
+		Urgency:       urgency,
+		PricePerWord:  rule.PricePerWord,
+		PricePerPage:  rule.PricePerPage,
+		Multiplier:    multiplier,
+		MinimumCharge: rule.MinimumCharge,
+		Amount:        RoundMoney(amount),
+		QuotedAt:      &now,
+	}, nil
+}
+
+// RoundMoney rounds an amount to two decimals
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
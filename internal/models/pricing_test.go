@@ -0,0 +1,115 @@
+package models
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestSpecificity(t *testing.T) {
+	document := Document{SourceLanguage: "en", TargetLanguage: "id", Category: CategoryEngineering}
+
+	tests := []struct {
+		name        string
+		rule        PricingRule
+		specificity int
+	}{
+		{"matches anything", PricingRule{}, 0},
+		{"category", PricingRule{Category: CategoryEngineering}, 1},
+		{"source language", PricingRule{SourceLanguage: "en"}, 2},
+		{"target language", PricingRule{TargetLanguage: "id"}, 2},
+		{"language pair", PricingRule{SourceLanguage: "en", TargetLanguage: "id"}, 4},
+		{"everything", PricingRule{SourceLanguage: "en", TargetLanguage: "id", Category: CategoryEngineering}, 5},
+		{"other category", PricingRule{SourceLanguage: "en", TargetLanguage: "id", Category: CategoryGeneral}, -1},
+		{"other source language", PricingRule{SourceLanguage: "fr"}, -1},
+		{"reversed pair", PricingRule{SourceLanguage: "id", TargetLanguage: "en"}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Specificity(&document); got != tt.specificity {
+				t.Fatalf("specificity %d, want %d", got, tt.specificity)
+			}
+		})
+	}
+}
+
+func TestGlobalPricingRule(t *testing.T) {
+	rule := GlobalPricingRule(&Settings{PricePerWord: 0.1})
+	if rule.PricePerWord != 0.1 || rule.PricePerPage != 25 || rule.MinimumCharge != 0 || rule.ID != 0 {
+		t.Fatalf("global rule %+v", rule)
+	}
+	if rule.Specificity(&Document{SourceLanguage: "en", TargetLanguage: "id", Category: CategoryGeneral}) != 0 {
+		t.Fatal("the global rule doesn't apply to every document")
+	}
+}
+
+func TestNewQuote(t *testing.T) {
+	rule := PricingRule{Model: gorm.Model{ID: 7}, PricePerWord: 0.1, PricePerPage: 20, MinimumCharge: 50}
+
+	tests := []struct {
+		name       string
+		words      int
+		pages      int
+		rule       PricingRule
+		multiplier float64
+		amount     float64
+	}{
+		{"per word", 1000, 4, rule, 1, 100},
+		{"per page when words are unknown", 0, 4, rule, 1, 80},
+		{"urgency multiplier", 1000, 4, rule, 1.5, 150},
+		{"minimum charge", 100, 1, rule, 1, 50},
+		{"minimum charge after the multiplier", 400, 2, rule, 1.5, 60},
+		{"multiplier below the minimum charge", 400, 2, rule, 1.2, 50},
+		{"rounded to cents", 333, 0, PricingRule{PricePerWord: 0.0333}, 1, 11.09},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Document{WordCount: tt.words, NumberOfPages: tt.pages}
+			quote, err := NewQuote(&d, tt.rule, UrgencyExpress, tt.multiplier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Amount != tt.amount {
+				t.Fatalf("amount %.2f, want %.2f", quote.Amount, tt.amount)
+			}
+			if quote.RuleID != tt.rule.ID || quote.Urgency != UrgencyExpress || quote.Multiplier != tt.multiplier || quote.QuotedAt == nil {
+				t.Fatalf("quote %+v doesn't record how it was priced", quote)
+			}
+		})
+	}
+}
+
+func TestNewQuoteWithoutAPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		document Document
+		rule     PricingRule
+	}{
+		{"nothing to count", Document{}, PricingRule{PricePerWord: 0.1, PricePerPage: 20}},
+		{"words without a price per word", Document{WordCount: 1000}, PricingRule{PricePerPage: 20}},
+		{"pages without a price per page", Document{NumberOfPages: 3}, PricingRule{PricePerWord: 0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if quote, err := NewQuote(&tt.document, tt.rule, UrgencyStandard, 1); err == nil {
+				t.Fatalf("quoted %.2f", quote.Amount)
+			}
+		})
+	}
+}
+
+func TestQuotePrice(t *testing.T) {
+	tests := []struct {
+		quote Quote
+		price float64
+	}{
+		{Quote{Amount: 100}, 100},
+		{Quote{Amount: 90, Discount: 10}, 100},
+		{Quote{Amount: 0.1, Discount: 0.2}, 0.3},
+	}
+	for _, tt := range tests {
+		if got := tt.quote.Price(); got != tt.price {
+			t.Errorf("price of %+v is %v, want %v", tt.quote, got, tt.price)
+		}
+	}
+}
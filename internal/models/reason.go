@@ -0,0 +1,64 @@
+package models
+
+import "errors"
+
+const (
+	// ReasonOther needs a comment explaining it
+	ReasonOther = "other"
+	// ReasonNoResponse is recorded by the scheduler when an assignment expires, it can't be picked
+	ReasonNoResponse = "no_response"
+)
+
+// ReasonCodes are the reasons that can be given for a rejection or a decline, keyed by the
+// event action they apply to and mapped to the text shown to the other party
+var ReasonCodes = map[string]map[string]string{
+	EventRejected: {
+		"unreadable":           "The document can't be read",
+		"unsupported_language": "The language pair isn't offered",
+		"prohibited_content":   "The content can't be translated by us",
+		"duplicate":            "The document was already submitted",
+		ReasonOther:            "Other",
+	},
+	EventTranslationRejected: {
+		"mistranslation": "Parts of the meaning were translated incorrectly",
+		"incomplete":     "Parts of the document weren't translated",
+		"terminology":    "Terminology is wrong or inconsistent",
+		"formatting":     "Layout doesn't match the original",
+		"grammar":        "Grammar or spelling mistakes",
+		ReasonOther:      "Other",
+	},
+	EventAssignmentDeclined: {
+		"no_capacity":        "Too much work at the moment",
+		"outside_expertise":  "The subject is outside the translator's expertise",
+		"deadline_too_short": "The deadline is too short",
+		"unavailable":        "Not available in this period",
+		ReasonOther:          "Other",
+	},
+	ActionApplicationRejected: {
+		"insufficient_test":       "The test translation didn't meet our standard",
+		"missing_credentials":     "The CV or certificates are missing or can't be verified",
+		"unsupported_languages":   "We don't offer the translator's languages",
+		"insufficient_experience": "Not enough translation experience",
+		ReasonOther:               "Other",
+	},
+}
+
+var (
+	ErrReasonRequired     = errors.New("reason_code is required")
+	ErrUnknownReason      = errors.New("unknown reason_code")
+	ErrReasonNeedsComment = errors.New("a comment is required when the reason is 'other'")
+)
+
+// ValidateReason checks a reason code given for an event action
+func ValidateReason(action, code, comment string) error {
+	if code == "" {
+		return ErrReasonRequired
+	}
+	if _, ok := ReasonCodes[action][code]; !ok {
+		return ErrUnknownReason
+	}
+	if code == ReasonOther && comment == "" {
+		return ErrReasonNeedsComment
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateApplicationRejectionReason(t *testing.T) {
+	tests := []struct {
+		code, comment string
+		want          error
+	}{
+		{"insufficient_test", "", nil},
+		{"", "", ErrReasonRequired},
+		{"mistranslation", "", ErrUnknownReason}, // Only valid for rejected translations
+		{ReasonOther, "", ErrReasonNeedsComment},
+		{ReasonOther, "Applied twice", nil},
+	}
+	for _, tt := range tests {
+		if err := ValidateReason(ActionApplicationRejected, tt.code, tt.comment); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateReason(%q, %q) = %v, want %v", tt.code, tt.comment, err, tt.want)
+		}
+	}
+}
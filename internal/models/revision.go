@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultRevisionWindowDays = 14
+	DefaultMaxRevisionRounds  = 2
+)
+
+// RevisionRequest is a customer asking the translator to rework a delivered translation
+type RevisionRequest struct {
+	gorm.Model
+	DocumentID    uint       `gorm:"not null;index"`
+	RequestedByID uint       `gorm:"not null"`
+	Round         int        `gorm:"not null"` // 1 for the first revision of the document
+	Version       int        // Delivered version the revision is about
+	Reason        string     `gorm:"type:text;not null"`
+	ResolvedAt    *time.Time // Set when the revised translation is delivered
+}
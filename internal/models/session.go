@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a login that can be refreshed and revoked. Only hashes of refresh tokens
+// are stored. The hash of the previous token is kept to spot a stolen token being reused.
+type Session struct {
+	gorm.Model
+	UserID            uint   `gorm:"not null;index"`
+	RefreshTokenHash  string `gorm:"not null;uniqueIndex"`
+	PreviousTokenHash string `gorm:"index"`
+	ExpiresAt         time.Time
+	RevokedAt         *time.Time
+	LastUsedAt        time.Time
+	UserAgent         string
+	IP                string
+}
+
+// Active reports whether the session can still be used
+func (s *Session) Active() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
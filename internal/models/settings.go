@@ -7,4 +7,12 @@ import (
 type Settings struct {
 	gorm.Model
 	PricePerWord float64 `gorm:"not null"`
-} 
\ No newline at end of file
+	AutoAssign   bool    // Assign paid documents to the best matching translator automatically
+	// Customers can ask for revisions this many days after delivery, up to MaxRevisionRounds times
+	RevisionWindowDays int `gorm:"not null;default:14"`
+	MaxRevisionRounds  int `gorm:"not null;default:2"`
+	// Share of the price refunded and paid to the translator when a document is cancelled after assignment
+	CancellationRefundPercent       int     `gorm:"not null;default:50"`
+	CancellationCompensationPercent int     `gorm:"not null;default:30"`
+	TaxRate                         float64 // Percent of tax included in quoted prices, shown on invoices
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// AdminSubscription narrows the notifications an admin receives. Admins without
+// any subscription receive every admin notification.
+type AdminSubscription struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UserID     uint   `gorm:"not null;index"`
+	DocumentID uint   // 0 when subscribing to a category
+	Category   string // Empty when subscribing to a single document
+}
+
+func (s *AdminSubscription) Validate() error {
+	if (s.DocumentID == 0) == (s.Category == "") {
+		return errors.New("subscribe to either a document or a category")
+	}
+	if s.Category != "" {
+		switch s.Category {
+		case CategoryGeneral, CategoryEngineering, CategorySocialSciences:
+		default:
+			return errors.New("invalid category: must be one of 'general', 'engineering', or 'social sciences'")
+		}
+	}
+	return nil
+}
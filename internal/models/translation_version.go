@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Verdicts an admin gives a translation version
+const (
+	VersionPending  = "pending"
+	VersionApproved = "approved"
+	VersionRejected = "rejected"
+)
+
+// TranslationVersion is one upload of a translated file. Every upload gets the next
+// number and its file is kept, so rejected and earlier delivered versions stay available.
+type TranslationVersion struct {
+	gorm.Model
+	DocumentID   uint   `gorm:"not null;uniqueIndex:idx_translation_version"`
+	Version      int    `gorm:"not null;uniqueIndex:idx_translation_version"` // 1 for the first upload
+	FileKey      string `gorm:"not null"`
+	FileName     string
+	UploadedByID uint   `gorm:"not null"` // Translator who uploaded this version
+	Verdict      string `gorm:"not null;default:'pending'"`
+	ReviewedByID uint   // Admin who approved or rejected the version
+	ReviewedAt   *time.Time
+	DeliveredAt  *time.Time // Set when the version was approved and sent to the customer
+}
+
+// Delivered reports whether the customer has received this version
+func (v *TranslationVersion) Delivered() bool {
+	return v.Verdict == VersionApproved
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"errors"
+	"net/mail"
+	"strings"
 
 	"github.com/lib/pq"
 	"gorm.io/gorm"
@@ -17,17 +19,46 @@ type User struct {
 	gorm.Model
 	Username            string
 	Email               string `gorm:"unique"`
-	Password            string
+	Password            string `json:"-"` // Never sent back to clients
 	Role                string
 	ProficientLanguages pq.StringArray `gorm:"type:text[]"`
 	Categories          pq.StringArray `gorm:"type:text[];default:'{}'"`
 	Ratings             []Rating       `gorm:"foreignKey:TranslatorID"`
 	Status              string
+	EmailNotifications  bool    `gorm:"default:false"` // Opt-in to receive notification emails
+	Verified            bool    `gorm:"not null;default:false"`
+	VettingStatus       string  // Translators only, see the Vetting* constants
+	VettingNote         string  // Reason given with the last vetting decision
+	VettingReasonCode   string  // One of ReasonCodes[ActionApplicationRejected] when rejected
+	Bio                 string  `gorm:"type:text"`
+	Available           bool    `gorm:"not null;default:true"` // Translators can pause new assignments
+	MaxConcurrentJobs   int     `gorm:"not null;default:3"`    // Assigned and unfinished documents a translator takes at once
+	TimeZone            string  `gorm:"not null;default:'Asia/Jakarta'"`
+	WordsPerDay         int     `gorm:"not null;default:2000"`            // Throughput used to estimate delivery dates
+	PayRateType         string  `gorm:"not null;default:'revenue_share'"` // Translators only, see the Pay* constants
+	PayRate             float64 `gorm:"not null;default:70"`
 }
 
 // Validate validates user fields based on their role
 func (u *User) Validate() error {
+	if strings.TrimSpace(u.Username) == "" {
+		return errors.New("username is required")
+	}
+	if _, err := mail.ParseAddress(u.Email); err != nil {
+		return errors.New("invalid email address")
+	}
+	switch u.Role {
+	case RoleUser, RoleTranslator, RoleAdmin:
+	default:
+		return errors.New("invalid role: must be one of 'user', 'translator', or 'admin'")
+	}
+
 	if u.Role == RoleTranslator {
+		for _, language := range u.ProficientLanguages {
+			if strings.TrimSpace(language) == "" {
+				return errors.New("proficient languages cannot be empty")
+			}
+		}
 		for _, category := range u.Categories {
 			switch category {
 			case CategoryGeneral, CategoryEngineering, CategorySocialSciences:
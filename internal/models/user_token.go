@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenPasswordReset = "password_reset"
+)
+
+// UserToken is a single-use token mailed to a user. Only its signature is stored.
+type UserToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"not null;index"`
+	Purpose   string `gorm:"not null"`
+	TokenHash string `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Vetting statuses of translator applications, only approved translators are matched to documents
+const (
+	VettingPendingReview = "PendingReview"
+	VettingTestAssigned  = "TestAssigned"
+	VettingApproved      = "Approved"
+	VettingRejected      = "Rejected"
+)
+
+// ActionApplicationRejected keys the reasons for turning an application down in ReasonCodes.
+// Applications have no document, so it is recorded on the translator instead of as a DocumentEvent.
+const ActionApplicationRejected = "application_rejected"
+
+const (
+	CredentialCV          = "cv"
+	CredentialCertificate = "certificate"
+)
+
+// TranslatorCredential is a CV or certificate a translator uploaded with their application
+type TranslatorCredential struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index"`
+	Kind     string `gorm:"not null"`
+	FileKey  string `gorm:"not null"`
+	FileName string
+}
+
+func (c *TranslatorCredential) Validate() error {
+	switch c.Kind {
+	case CredentialCV, CredentialCertificate:
+		return nil
+	default:
+		return errors.New("invalid kind: must be one of 'cv' or 'certificate'")
+	}
+}
+
+// TestTranslation is a short passage an admin asks an applicant to translate before deciding
+type TestTranslation struct {
+	gorm.Model
+	TranslatorID   uint   `gorm:"not null;index"`
+	AssignedByID   uint   `gorm:"not null"`
+	SourceLanguage string `gorm:"not null"`
+	TargetLanguage string `gorm:"not null"`
+	Instructions   string `gorm:"type:text"`
+	SourceText     string `gorm:"type:text;not null"`
+	SubmittedText  string `gorm:"type:text"`
+	SubmittedAt    *time.Time
+}
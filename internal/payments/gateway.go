@@ -0,0 +1,129 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GatewayConfig describes a hosted payment gateway. The server key authenticates our
+// requests, the webhook secret signs the gateway's callbacks.
+type GatewayConfig struct {
+	BaseURL       string
+	ServerKey     string
+	WebhookSecret string
+}
+
+// Gateway talks to a hosted checkout API in the style of Midtrans, Xendit or Stripe:
+// POST {BaseURL}/payment-intents creates a payment and returns a page to pay on, and the
+// gateway calls back with a JSON body signed in the X-Signature header.
+type Gateway struct {
+	cfg    GatewayConfig
+	client *http.Client
+}
+
+func NewGateway(cfg GatewayConfig) (*Gateway, error) {
+	if cfg.BaseURL == "" || cfg.ServerKey == "" || cfg.WebhookSecret == "" {
+		return nil, errors.New("the payment gateway requires PAYMENT_GATEWAY_URL, PAYMENT_SERVER_KEY and PAYMENT_WEBHOOK_SECRET")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &Gateway{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (g *Gateway) Name() string {
+	return "gateway"
+}
+
+func (g *Gateway) CreateIntent(ctx context.Context, req IntentRequest) (Intent, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"reference":   req.Reference,
+		"amount":      req.Amount,
+		"currency":    req.Currency,
+		"description": req.Description,
+		"customer": map[string]string{
+			"name":  req.CustomerName,
+			"email": req.CustomerEmail,
+		},
+	})
+	if err != nil {
+		return Intent{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.cfg.BaseURL+"/payment-intents", bytes.NewReader(payload))
+	if err != nil {
+		return Intent{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+g.cfg.ServerKey)
+	// Retrying with the same reference must not charge twice
+	httpReq.Header.Set("Idempotency-Key", req.Reference)
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return Intent{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Intent{}, fmt.Errorf("payment gateway returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var created struct {
+		ID         string     `json:"id"`
+		PaymentURL string     `json:"payment_url"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return Intent{}, fmt.Errorf("invalid payment gateway response: %w", err)
+	}
+	if created.ID == "" || created.PaymentURL == "" {
+		return Intent{}, errors.New("invalid payment gateway response: id and payment_url are required")
+	}
+
+	return Intent{ProviderID: created.ID, PaymentURL: created.PaymentURL, ExpiresAt: created.ExpiresAt}, nil
+}
+
+func (g *Gateway) ParseWebhook(header func(key string) string, body []byte) (Event, error) {
+	return parseSignedWebhook(g.cfg.WebhookSecret, header, body)
+}
+
+// parseSignedWebhook verifies and decodes a callback body of the form
+// {"id": "...", "reference": "...", "status": "paid", "amount": 150000}
+func parseSignedWebhook(secret string, header func(key string) string, body []byte) (Event, error) {
+	if err := verify(secret, body, header(SignatureHeader)); err != nil {
+		return Event{}, err
+	}
+
+	var callback struct {
+		ID        string  `json:"id"`
+		Reference string  `json:"reference"`
+		Status    Status  `json:"status"`
+		Amount    float64 `json:"amount"`
+	}
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return Event{}, fmt.Errorf("invalid webhook body: %w", err)
+	}
+	if callback.Reference == "" {
+		return Event{}, errors.New("invalid webhook body: reference is required")
+	}
+	switch callback.Status {
+	case StatusPending, StatusPaid, StatusFailed, StatusExpired:
+	default:
+		return Event{}, fmt.Errorf("invalid webhook body: unknown status %q", callback.Status)
+	}
+
+	return Event{
+		Reference:  callback.Reference,
+		ProviderID: callback.ID,
+		Status:     callback.Status,
+		Amount:     callback.Amount,
+	}, nil
+}
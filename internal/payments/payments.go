@@ -0,0 +1,127 @@
+// Package payments creates payment intents with an online payment gateway and verifies
+// the signed callbacks the gateway sends when a payment settles.
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultCurrency is charged when PAYMENT_CURRENCY isn't set
+const DefaultCurrency = "IDR"
+
+// SignatureHeader carries the hex HMAC-SHA256 of the raw webhook body
+const SignatureHeader = "X-Signature"
+
+// Status of a payment intent as reported by the gateway
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusPaid    Status = "paid"
+	StatusFailed  Status = "failed"
+	StatusExpired Status = "expired"
+)
+
+var (
+	// ErrNotConfigured is returned by New when no provider is set up
+	ErrNotConfigured = errors.New("no payment provider configured")
+	// ErrInvalidSignature is returned for webhook calls that weren't signed with the shared secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// IntentRequest asks the gateway to collect an amount for one of our references
+type IntentRequest struct {
+	Reference     string // Our own unique reference, echoed back in webhooks
+	Amount        float64
+	Currency      string
+	Description   string
+	CustomerName  string
+	CustomerEmail string
+}
+
+// Intent is a payment the gateway is waiting for
+type Intent struct {
+	ProviderID string // The gateway's ID of the payment
+	PaymentURL string // Page the customer pays on
+	ExpiresAt  *time.Time
+}
+
+// Event is a verified webhook callback about a payment
+type Event struct {
+	Reference  string
+	ProviderID string
+	Status     Status
+	Amount     float64
+}
+
+// Provider is an online payment gateway
+type Provider interface {
+	// Name identifies the provider in stored payment intents
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (Intent, error)
+	// ParseWebhook verifies the signature of a callback and decodes it. header returns
+	// the value of a request header.
+	ParseWebhook(header func(key string) string, body []byte) (Event, error)
+}
+
+// New builds the provider configured through the PAYMENT_PROVIDER environment variable
+func New() (Provider, error) {
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+
+	switch provider := os.Getenv("PAYMENT_PROVIDER"); provider {
+	case "":
+		return nil, ErrNotConfigured
+	case "fake":
+		if secret == "" {
+			return nil, errors.New("the fake payment provider requires PAYMENT_WEBHOOK_SECRET")
+		}
+		return NewFake(secret), nil
+	case "gateway":
+		gateway, err := NewGateway(GatewayConfig{
+			BaseURL:       os.Getenv("PAYMENT_GATEWAY_URL"),
+			ServerKey:     os.Getenv("PAYMENT_SERVER_KEY"),
+			WebhookSecret: secret,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return gateway, nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", provider)
+	}
+}
+
+// Currency returns the currency payments are made in
+func Currency() string {
+	if currency := os.Getenv("PAYMENT_CURRENCY"); currency != "" {
+		return currency
+	}
+	return DefaultCurrency
+}
+
+// Sign returns the signature of a webhook body, as sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	return hex.EncodeToString(mac(secret, body))
+}
+
+// verify checks a signature made by Sign without leaking timing information
+func verify(secret string, body []byte, signature string) error {
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, mac(secret, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,81 @@
+package payments
+
+import (
+	"errors"
+	"testing"
+)
+
+const testSecret = "whsec_test"
+
+func headers(signature string) func(string) string {
+	return func(key string) string {
+		if key == SignatureHeader {
+			return signature
+		}
+		return ""
+	}
+}
+
+func TestFakeWebhookRoundTrip(t *testing.T) {
+	sent := Event{Reference: "DOC1-abc", ProviderID: "fake_1", Status: StatusPaid, Amount: 150000}
+	body, signature, err := FakeWebhook(testSecret, sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewFake(testSecret).ParseWebhook(headers(signature), body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if got != sent {
+		t.Fatalf("ParseWebhook = %+v, want %+v", got, sent)
+	}
+}
+
+func TestParseSignedWebhookRejectsBadSignatures(t *testing.T) {
+	body, signature, err := FakeWebhook(testSecret, Event{Reference: "DOC1-abc", Status: StatusPaid, Amount: 150000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherSignature, err := FakeWebhook("another secret", Event{Reference: "DOC1-abc", Status: StatusPaid, Amount: 150000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+	}{
+		{"missing", body, ""},
+		{"not hex", body, "not-a-signature"},
+		{"other secret", body, otherSignature},
+		{"truncated", body, signature[:len(signature)-2]},
+		{"tampered body", []byte(`{"id":"","reference":"DOC1-abc","status":"paid","amount":1}`), signature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSignedWebhook(testSecret, headers(tt.signature), tt.body); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("parseSignedWebhook error = %v, want ErrInvalidSignature", err)
+			}
+			if err := verify(testSecret, tt.body, tt.signature); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("verify error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestParseSignedWebhookRejectsInvalidBodies(t *testing.T) {
+	for name, body := range map[string]string{
+		"not json":          `paid`,
+		"missing reference": `{"id":"fake_1","status":"paid","amount":150000}`,
+		"unknown status":    `{"id":"fake_1","reference":"DOC1-abc","status":"refunded","amount":150000}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseSignedWebhook(testSecret, headers(Sign(testSecret, []byte(body))), []byte(body))
+			if err == nil || errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("parseSignedWebhook error = %v, want an invalid body error", err)
+			}
+		})
+	}
+}
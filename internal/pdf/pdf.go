@@ -0,0 +1,156 @@
+// Package pdf writes simple text-only PDF files, such as invoices, using the standard
+// Helvetica fonts every PDF reader has built in. Text outside Latin-1 is printed as "?".
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+// Font is one of the built-in fonts
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// fontNames are the resource names fonts are referenced by in content streams
+var fontNames = map[Font]string{Regular: "/F1", Bold: "/F2"}
+
+// Document is a PDF being built page by page
+type Document struct {
+	pages []*Page
+}
+
+// Page is a single A4 page. Coordinates are in points from the bottom left corner.
+type Page struct {
+	content bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage appends an empty page and returns it
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Text writes s with its baseline starting at x, y
+func (p *Page) Text(x, y, size float64, font Font, s string) {
+	fmt.Fprintf(&p.content, "BT %s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", fontNames[font], size, x, y, escape(s))
+}
+
+// TextRight writes s so that it ends at x, used for columns of amounts
+func (p *Page) TextRight(x, y, size float64, font Font, s string) {
+	p.Text(x-TextWidth(s, size, font), y, size, font, s)
+}
+
+// Line draws a thin line from x1, y1 to x2, y2
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// TextWidth returns how wide s is printed in points
+func TextWidth(s string, size float64, font Font) float64 {
+	widths := helveticaWidths
+	if font == Bold {
+		widths = helveticaBoldWidths
+	}
+
+	var units int
+	for _, r := range s {
+		if r >= 32 && r < 32+rune(len(widths)) {
+			units += widths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// Bytes serializes the document
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	// Objects are numbered from 1 in the order they are written
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3 and 4 fonts, then a page and its content per page
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// escape encodes s as the body of a PDF string literal in WinAnsiEncoding
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			// WinAnsiEncoding matches Latin-1 in this range
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Glyph widths of the printable ASCII characters, from the standard font metrics
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+var helveticaBoldWidths = []int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,83 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestBytesCrossReferencesEveryObject(t *testing.T) {
+	doc := New()
+	doc.AddPage().Text(50, 800, 12, Regular, "First page")
+	doc.AddPage().Text(50, 800, 12, Bold, "Second page")
+	data := doc.Bytes()
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("no startxref at the end of %q", data[max(0, len(data)-60):])
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 9\n0000000000 65535 f \n")) {
+		t.Fatalf("startxref %d points at %q", xref, data[xref:min(len(data), xref+30)])
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(data[xref:], -1)
+	// Catalog, page tree, two fonts, then a page and its content per page
+	if len(entries) != 8 {
+		t.Fatalf("%d objects in the cross-reference table, want 8", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("object %d is at %q, not %d", i+1, data[offset:min(len(data), offset+20)], offset)
+		}
+	}
+	if !bytes.Contains(data, []byte("trailer\n<< /Size 9 /Root 1 0 R >>")) {
+		t.Fatal("trailer doesn't count the objects")
+	}
+}
+
+func TestStreamLengthMatchesContent(t *testing.T) {
+	doc := New()
+	doc.AddPage().Text(50, 800, 12, Regular, "Total")
+	data := doc.Bytes()
+
+	match := regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`).FindSubmatchIndex(data)
+	if match == nil {
+		t.Fatal("no content stream")
+	}
+	length, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+	if !bytes.HasPrefix(data[match[1]+length:], []byte("endstream")) {
+		t.Fatalf("stream of length %d doesn't end at endstream", length)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"Annual report", "Annual report"},
+		{"Report (final)", `Report \(final\)`},
+		{`C:\files`, `C:\\files`},
+		{"Café", `Caf\351`},
+		{"Jl. Merdeka №5", "Jl. Merdeka ?5"},
+		{"翻译", "??"},
+		{"line\nbreak", "line?break"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.out {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.out)
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := TextWidth("AV", 10, Regular); got != 13.34 {
+		t.Fatalf("regular width %v", got)
+	}
+	if TextWidth("Total", 10, Bold) <= TextWidth("Total", 10, Regular) {
+		t.Fatal("bold text isn't wider")
+	}
+}
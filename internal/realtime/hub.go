@@ -0,0 +1,80 @@
+package realtime
+
+import "sync"
+
+const (
+	EventNotification = "notification"
+	EventDiscussion   = "discussion"
+)
+
+// clientBuffer is how many events a connection may fall behind before new ones are dropped
+const clientBuffer = 16
+
+// Event is something pushed to the connected clients of a user
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+// Hub fans events out to every open connection of a user
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[uint]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: make(map[uint]map[chan Event]struct{})}
+}
+
+// Subscribe registers a connection for a user. The returned function must be called
+// once the connection is closed.
+func (h *Hub) Subscribe(userID uint) (<-chan Event, func()) {
+	ch := make(chan Event, clientBuffer)
+
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[chan Event]struct{})
+	}
+	h.clients[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.clients[userID], ch)
+			if len(h.clients[userID]) == 0 {
+				delete(h.clients, userID)
+			}
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Publish sends an event to every connection of the users. It never blocks, a
+// connection that is too slow misses the event and has to refetch.
+func (h *Hub) Publish(userIDs []uint, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for ch := range h.clients[userID] {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+var defaultHub = NewHub()
+
+// Subscribe registers a connection on the shared hub
+func Subscribe(userID uint) (<-chan Event, func()) {
+	return defaultHub.Subscribe(userID)
+}
+
+// Publish sends an event through the shared hub
+func Publish(userIDs []uint, event Event) {
+	defaultHub.Publish(userIDs, event)
+}
@@ -0,0 +1,87 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+)
+
+// receive waits briefly for the next event on ch
+func receive(t *testing.T, ch <-chan Event) (Event, bool) {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event, true
+	case <-time.After(100 * time.Millisecond):
+		return Event{}, false
+	}
+}
+
+func TestPublishReachesEveryConnectionOfTheUser(t *testing.T) {
+	hub := NewHub()
+	first, unsubscribeFirst := hub.Subscribe(1)
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := hub.Subscribe(1)
+	defer unsubscribeSecond()
+	other, unsubscribeOther := hub.Subscribe(2)
+	defer unsubscribeOther()
+
+	hub.Publish([]uint{1}, Event{Type: EventNotification, Data: "hello"})
+
+	for _, ch := range []<-chan Event{first, second} {
+		event, ok := receive(t, ch)
+		if !ok || event.Type != EventNotification || event.Data != "hello" {
+			t.Fatalf("connection got %+v, %v", event, ok)
+		}
+	}
+	if event, ok := receive(t, other); ok {
+		t.Fatalf("another user got %+v", event)
+	}
+}
+
+func TestUnsubscribeStopsEvents(t *testing.T) {
+	hub := NewHub()
+	events, unsubscribe := hub.Subscribe(1)
+	unsubscribe()
+	// Calling it again, as a deferred call after an early one would, is harmless
+	unsubscribe()
+
+	hub.Publish([]uint{1}, Event{Type: EventDiscussion})
+	if event, ok := receive(t, events); ok {
+		t.Fatalf("unsubscribed connection got %+v", event)
+	}
+	if _, ok := hub.clients[1]; ok {
+		t.Fatal("the user is still registered without connections")
+	}
+}
+
+func TestSlowConnectionMissesEventsWithoutBlocking(t *testing.T) {
+	hub := NewHub()
+	slow, unsubscribeSlow := hub.Subscribe(1)
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := hub.Subscribe(1)
+	defer unsubscribeFast()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < clientBuffer+1; i++ {
+			hub.Publish([]uint{1}, Event{Type: EventNotification, Data: i})
+			// The fast connection keeps up
+			<-fast
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a connection that doesn't read")
+	}
+
+	for i := 0; i < clientBuffer; i++ {
+		if event, ok := receive(t, slow); !ok || event.Data != i {
+			t.Fatalf("event %d was %+v, %v", i, event, ok)
+		}
+	}
+	if event, ok := receive(t, slow); ok {
+		t.Fatalf("slow connection got %+v past its buffer", event)
+	}
+}
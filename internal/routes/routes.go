@@ -3,12 +3,14 @@ package routes
 import (
 	"translation-app-backend/internal/handlers"
 	"translation-app-backend/internal/middleware"
+	"translation-app-backend/internal/payments"
+	"translation-app-backend/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(app *fiber.App, db *gorm.DB) {
+func SetupRoutes(app *fiber.App, db *gorm.DB, store storage.BlobStore, provider payments.Provider) {
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Ini API untuk web app sistem layanan penerjemahan dokumen.")
 	})
@@ -16,58 +18,146 @@ func SetupRoutes(app *fiber.App, db *gorm.DB) {
 	// auth routes
 	app.Post("api/register", handlers.Register(db))
 	app.Post("api/login", handlers.Login(db))
+	app.Post("api/token/refresh", handlers.RefreshToken(db))
+	app.Post("api/password/forgot", handlers.ForgotPassword(db))
+	app.Post("api/password/reset", handlers.ResetPassword(db))
+	app.Get("api/verify-email", handlers.VerifyEmail(db))
 	app.Post("api/mail", handlers.Mail(db))
 
+	// Called by the payment gateway, authenticated by the signature of the body
+	app.Post("api/payments/webhook", handlers.PaymentWebhook(db, provider))
+
+	// Registered before the /api group so the header-only Authenticated middleware doesn't run first
+	app.Get("/api/stream", middleware.AuthenticatedStream(db), handlers.StreamEvents(db))
+
 	// user routes
 	api := app.Group("/api")
-	api.Use(middleware.Authenticated())
+	api.Use(middleware.Authenticated(db))
 
-	api.Post("/upload", middleware.Authenticated(), handlers.UploadDocument(db))
-	api.Get("/documents", middleware.Authenticated(), handlers.GetDocuments(db))
-	api.Get("/documents/:id", middleware.Authenticated(), handlers.GetDocument(db))
-	api.Get("/documents/:id/discussions", middleware.Authenticated(), handlers.GetDiscussions(db))
-	api.Post("/documents/:id/discussions", middleware.Authenticated(), handlers.PostDiscussion(db))
-	api.Post("/documents/:id/upload-receipt", handlers.UploadPaymentReceipt(db))
-	api.Get("/documents/:id/download", middleware.Authenticated(), handlers.DownloadTranslatedDocument(db))
+	api.Post("/upload", handlers.UploadDocument(db, store))
+	api.Get("/documents", handlers.GetDocuments(db))
+	api.Get("/documents/:id", middleware.DocumentAccess(db), handlers.GetDocument(db))
+	api.Get("/documents/:id/discussions", middleware.DocumentAccess(db), handlers.GetDiscussions(db))
+	api.Post("/documents/:id/discussions", middleware.DocumentAccess(db), handlers.PostDiscussion(db))
+	api.Get("/documents/:id/quote", middleware.DocumentAccess(db), handlers.GetDocumentQuote(db))
+	api.Post("/documents/:id/accept-quote", middleware.DocumentAccess(db), handlers.AcceptQuote(db))
+	api.Post("/documents/:id/coupon", middleware.DocumentAccess(db), handlers.ApplyCoupon(db))
+	api.Delete("/documents/:id/coupon", middleware.DocumentAccess(db), handlers.RemoveCoupon(db))
+	api.Post("/documents/:id/upload-receipt", middleware.DocumentAccess(db), handlers.UploadPaymentReceipt(db, store))
+	api.Post("/documents/:id/pay", middleware.DocumentAccess(db), handlers.CreatePaymentIntent(db, provider))
+	api.Get("/documents/:id/payments", middleware.DocumentAccess(db), handlers.GetPaymentIntents(db))
+	api.Get("/documents/:id/invoices", middleware.DocumentAccess(db), handlers.GetDocumentInvoices(db))
+	api.Get("/documents/:id/invoices/:number", middleware.DocumentAccess(db), handlers.DownloadInvoice(db))
+	api.Get("/documents/:id/download", middleware.DocumentAccess(db), handlers.DownloadTranslatedDocument(db, store))
 	api.Post("/ratings", handlers.SubmitRating(db))
 	api.Get("/:id/average-rating", handlers.GetTranslatorAverageRating(db))
-	api.Get("/documents/:id/rating", handlers.GetRatings(db))
+	api.Get("/documents/:id/rating", middleware.DocumentAccess(db), handlers.GetRatings(db))
+	api.Get("/documents/:id/history", middleware.DocumentAccess(db), handlers.GetDocumentHistory(db))
+	api.Post("/documents/:id/revision-request", middleware.DocumentAccess(db), handlers.RequestRevision(db))
+	api.Get("/documents/:id/revisions", middleware.DocumentAccess(db), handlers.GetRevisionRequests(db))
+	api.Get("/documents/:id/versions", middleware.DocumentAccess(db), handlers.GetTranslationVersions(db))
+	api.Post("/documents/:id/cancel", middleware.DocumentAccess(db), handlers.CancelDocument(db))
+	api.Get("/documents/:id/cancellation", middleware.DocumentAccess(db), handlers.GetCancellation(db))
+	api.Get("/documents/:id/refunds/:refundId/proof", middleware.DocumentAccess(db), handlers.DownloadRefundProof(db, store))
+	api.Get("/documents/:id/versions/diff", middleware.DocumentAccess(db), handlers.DiffTranslationVersions(db, store))
+	api.Get("/documents/:id/versions/:version/download", middleware.DocumentAccess(db), handlers.DownloadTranslationVersion(db, store))
+
+	api.Post("/logout", handlers.Logout(db))
+	api.Post("/verify-email/resend", handlers.ResendVerificationEmail(db))
+	api.Get("/me", handlers.GetMe(db))
+	api.Patch("/me", handlers.UpdateMe(db))
+	api.Put("/me/password", handlers.ChangePassword(db))
+
+	api.Get("/reason-codes", handlers.GetReasonCodes())
 
 	api.Get("/notifications", handlers.FetchNotifications(db))
 	api.Post("/notifications/read", handlers.MarkNotificationsAsRead(db))
+	api.Put("/notifications/email", handlers.UpdateEmailNotifications(db))
 
 	// Admin routes
 	admin := app.Group("/api/admin")
-	admin.Use(middleware.Authenticated())
+	admin.Use(middleware.Authenticated(db))
 	admin.Use(middleware.AdminRequired())
 
 	admin.Post("/register", handlers.RegisterAdmin(db))
 	admin.Get("/documents", handlers.GetAllDocuments(db))
+	admin.Get("/documents/unassigned", handlers.GetUnassignedDocuments(db))
+	admin.Get("/documents/sla", handlers.GetSLADocuments(db))
 	admin.Get("/documents/:id", handlers.GetDocumentDetails(db))
-	admin.Get("/documents/:id/download", handlers.DownloadUserDocument(db))
+	admin.Get("/documents/:id/download", handlers.DownloadUserDocument(db, store))
 	admin.Post("/documents/:id/approve", handlers.ApproveDocument(db))
+	admin.Post("/documents/:id/quote", handlers.RequoteDocument(db))
 	admin.Post("/documents/:id/reject", handlers.RejectDocument(db))
 	admin.Get("/translators", handlers.GetTranslators(db))
+	admin.Patch("/users/:id", handlers.UpdateUser(db))
 	admin.Get("/translators/by-language", handlers.GetTranslatorsByLanguage(db))
 	admin.Post("/documents/:id/assign", handlers.AssignDocument(db))
+	admin.Get("/documents/:id/assignments", handlers.GetAssignmentAttempts(db))
 	admin.Delete("/translators/:id", handlers.DeleteTranslator(db))
-	admin.Get("/documents/:id/translated/download", handlers.DownloadTranslatedFile(db))
+	admin.Get("/translators/applications", handlers.GetTranslatorApplications(db))
+	admin.Get("/translators/:id/application", handlers.GetTranslatorApplication(db))
+	admin.Get("/translators/:id/credentials/:credentialId", handlers.DownloadCredential(db, store))
+	admin.Post("/translators/:id/test", handlers.AssignTestTranslation(db))
+	admin.Post("/translators/:id/approve", handlers.ApproveTranslator(db))
+	admin.Post("/translators/:id/reject", handlers.RejectTranslator(db))
+	admin.Get("/documents/:id/translated/download", handlers.DownloadTranslatedFile(db, store))
 	admin.Post("/documents/:id/translated/approve", handlers.ApproveTranslatedDocument(db))
 	admin.Post("/documents/:id/translated/reject", handlers.RejectTranslatedDocument(db))
-	admin.Get("/documents/:id/payment-receipt", handlers.DownloadPaymentReceipt(db))
+	admin.Get("/documents/:id/payment-receipt", handlers.DownloadPaymentReceipt(db, store))
 	admin.Post("/documents/:id/payment-approve", handlers.ApprovePayment(db))
+	admin.Post("/documents/:id/refunds", handlers.RecordRefund(db, store))
+	admin.Get("/invoices", handlers.GetInvoices(db))
+	admin.Get("/coupons", handlers.GetCoupons(db))
+	admin.Post("/coupons", handlers.CreateCoupon(db))
+	admin.Put("/coupons/:id", handlers.UpdateCoupon(db))
+	admin.Delete("/coupons/:id", handlers.DeleteCoupon(db))
+	admin.Get("/coupons/:id/redemptions", handlers.GetCouponRedemptions(db))
 	admin.Get("/mails", handlers.GetMailSubmissions(db))
+	admin.Get("/subscriptions", handlers.GetAdminSubscriptions(db))
+	admin.Post("/subscriptions", handlers.CreateAdminSubscription(db))
+	admin.Delete("/subscriptions/:id", handlers.DeleteAdminSubscription(db))
+	admin.Get("/audit", handlers.SearchDocumentEvents(db))
+	admin.Get("/reports/rejection-reasons", handlers.GetRejectionReasonReport(db))
+	admin.Get("/reports/application-rejection-reasons", handlers.GetApplicationRejectionReport(db))
+	admin.Put("/translators/:id/pay-rate", handlers.UpdatePayRate(db))
+	admin.Get("/payouts", handlers.GetPayouts(db))
+	admin.Post("/payouts", handlers.CreatePayout(db))
+	admin.Get("/payouts/:id/export", handlers.ExportPayout(db))
 	admin.Put("/settings/price", handlers.UpdatePricePerWord(db))
+	admin.Put("/settings/auto-assign", handlers.UpdateAutoAssign(db))
+	admin.Put("/settings/revisions", handlers.UpdateRevisionPolicy(db))
+	admin.Put("/settings/cancellation", handlers.UpdateCancellationPolicy(db))
+	admin.Put("/settings/tax", handlers.UpdateTaxRate(db))
+	admin.Get("/settings/pricing-rules", handlers.GetPricingRules(db))
+	admin.Post("/settings/pricing-rules", handlers.CreatePricingRule(db))
+	admin.Put("/settings/pricing-rules/:id", handlers.UpdatePricingRule(db))
+	admin.Delete("/settings/pricing-rules/:id", handlers.DeletePricingRule(db))
+	admin.Get("/settings/urgency-tiers", handlers.GetUrgencyTiers(db))
+	admin.Post("/settings/urgency-tiers", handlers.CreateUrgencyTier(db))
+	admin.Put("/settings/urgency-tiers/:id", handlers.UpdateUrgencyTier(db))
+	admin.Delete("/settings/urgency-tiers/:id", handlers.DeleteUrgencyTier(db))
 
 	// Group routes for translators
 	translators := app.Group("/api/translator")
-	translators.Use(middleware.Authenticated())
+	translators.Use(middleware.Authenticated(db))
 	translators.Use(middleware.TranslatorRequired())
 
 	translators.Get("/assigned-documents", handlers.GetAssignedDocuments(db))
 	translators.Get("/documents/:id", handlers.GetAssignedDocument(db))
 	translators.Post("/documents/:id/approve", handlers.ApproveAssignedDocument(db))
 	translators.Post("/documents/:id/decline", handlers.DeclineAssignedDocument(db))
-	translators.Get("/documents/:id/download", handlers.DownloadAssignedDocument(db))
-	translators.Post("/documents/:id/upload", handlers.UploadTranslatedDocument(db))
+	translators.Get("/documents/:id/download", handlers.DownloadAssignedDocument(db, store))
+	translators.Post("/documents/:id/upload", handlers.UploadTranslatedDocument(db, store))
+	translators.Patch("/profile", handlers.UpdateTranslatorProfile(db))
+	translators.Put("/categories", handlers.UpdateTranslatorCategories(db))
+	translators.Get("/availability", handlers.GetAvailability(db))
+	translators.Put("/availability", handlers.UpdateAvailability(db))
+	translators.Post("/time-off", handlers.CreateTimeOff(db))
+	translators.Delete("/time-off/:id", handlers.DeleteTimeOff(db))
+	translators.Get("/vetting", handlers.GetVettingStatus(db))
+	translators.Post("/credentials", handlers.UploadCredential(db, store))
+	translators.Post("/tests/:id/submit", handlers.SubmitTestTranslation(db))
+	translators.Get("/earnings", handlers.GetEarnings(db))
+	translators.Get("/bank-account", handlers.GetBankAccount(db))
+	translators.Put("/bank-account", handlers.UpdateBankAccount(db))
 }
@@ -0,0 +1,247 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"translation-app-backend/internal/database/databasetest"
+	"translation-app-backend/internal/models"
+	"translation-app-backend/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// fixture is an app with a delivered document, its owner, its translator, an admin and
+// a customer who has nothing to do with the document
+type fixture struct {
+	app      *fiber.App
+	document models.Document
+	tokens   map[string]string
+}
+
+func createUser(t *testing.T, db *gorm.DB, name string, role string) models.User {
+	t.Helper()
+	user := models.User{
+		Username: name,
+		Email:    fmt.Sprintf("%s-%d@example.test", name, time.Now().UnixNano()),
+		Role:     role,
+		Verified: true,
+	}
+	if role == models.RoleTranslator {
+		user.VettingStatus = models.VettingApproved
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// tokenFor signs an access token the way Login does, bound to a fresh session
+func tokenFor(t *testing.T, db *gorm.DB, user models.User) string {
+	t.Helper()
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: fmt.Sprintf("test-%d-%d", user.ID, time.Now().UnixNano()),
+		ExpiresAt:        time.Now().Add(time.Hour),
+		LastUsedAt:       time.Now(),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":  user.ID,
+		"sid":      session.ID,
+		"userRole": user.Role,
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	t.Setenv("SECRET", "test-secret")
+	db := databasetest.Open(t)
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	SetupRoutes(app, db, store, nil)
+
+	users := map[string]models.User{
+		"owner":      createUser(t, db, "owner", models.RoleUser),
+		"other":      createUser(t, db, "other", models.RoleUser),
+		"translator": createUser(t, db, "translator", models.RoleTranslator),
+		"admin":      createUser(t, db, "admin", models.RoleAdmin),
+	}
+
+	now := time.Now()
+	document := models.Document{
+		UserID:         users["owner"].ID,
+		TranslatorID:   users["translator"].ID,
+		State:          models.StateDelivered,
+		Title:          "Annual report",
+		Category:       models.CategoryGeneral,
+		SourceLanguage: "en",
+		TargetLanguage: "id",
+		DeliveredAt:    &now,
+		Quote:          models.Quote{Amount: 100, QuotedAt: &now},
+	}
+	if err := db.Create(&document).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := map[string]string{}
+	for name, user := range users {
+		tokens[name] = tokenFor(t, db, user)
+	}
+	return &fixture{app: app, document: document, tokens: tokens}
+}
+
+func (f *fixture) request(t *testing.T, method, path, who string, body string) int {
+	t.Helper()
+	status, _ := f.send(t, method, path, who, body)
+	return status
+}
+
+// send makes a request as who and decodes a JSON object response
+func (f *fixture) send(t *testing.T, method, path, who string, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+f.tokens[who])
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := f.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+func TestDocumentRoutesAreLimitedToOwnerTranslatorAndAdmin(t *testing.T) {
+	f := newFixture(t)
+	base := fmt.Sprintf("/api/documents/%d", f.document.ID)
+
+	routes := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, base, ""},
+		{http.MethodGet, base + "/discussions", ""},
+		{http.MethodPost, base + "/discussions", `{"message":"Is the glossary attached?"}`},
+		{http.MethodGet, base + "/rating", ""},
+		{http.MethodGet, base + "/history", ""},
+	}
+
+	for _, route := range routes {
+		for _, who := range []string{"owner", "translator", "admin"} {
+			if status := f.request(t, route.method, route.path, who, route.body); status != http.StatusOK {
+				t.Errorf("%s %s as %s returned %d, want 200", route.method, route.path, who, status)
+			}
+		}
+
+		// Other customers get the same answer as for a document that doesn't exist
+		if status := f.request(t, route.method, route.path, "other", route.body); status != http.StatusNotFound {
+			t.Errorf("%s %s as another customer returned %d, want 404", route.method, route.path, status)
+		}
+	}
+}
+
+func TestDocumentPriceIsOnlyShownToOwnerAndAdmin(t *testing.T) {
+	f := newFixture(t)
+	path := fmt.Sprintf("/api/documents/%d", f.document.ID)
+
+	for _, tt := range []struct {
+		who  string
+		sees bool
+	}{
+		{"owner", true},
+		{"admin", true},
+		{"translator", false},
+	} {
+		status, body := f.send(t, http.MethodGet, path, tt.who, "")
+		if status != http.StatusOK {
+			t.Fatalf("GET %s as %s returned %d, want 200", path, tt.who, status)
+		}
+		if body["Title"] != "Annual report" {
+			t.Errorf("%s got %v without the document details", tt.who, body)
+		}
+		for _, field := range []string{"Quote", "PaymentConfirmed", "PaymentReceiptKey", "PaymentReceiptFileName"} {
+			if _, ok := body[field]; ok != tt.sees {
+				t.Errorf("%s sees %s: %v, want %v", tt.who, field, ok, tt.sees)
+			}
+		}
+	}
+}
+
+func TestOtherCustomerCannotReachOwnerOnlyRoutes(t *testing.T) {
+	f := newFixture(t)
+	base := fmt.Sprintf("/api/documents/%d", f.document.ID)
+
+	for _, route := range []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, base + "/quote", ""},
+		{http.MethodPost, base + "/accept-quote", ""},
+		{http.MethodPost, base + "/upload-receipt", ""},
+		{http.MethodGet, base + "/download", ""},
+		{http.MethodGet, base + "/invoices", ""},
+		{http.MethodGet, base + "/payments", ""},
+		{http.MethodGet, base + "/cancellation", ""},
+	} {
+		if status := f.request(t, route.method, route.path, "other", route.body); status != http.StatusNotFound {
+			t.Errorf("%s %s as another customer returned %d, want 404", route.method, route.path, status)
+		}
+	}
+
+	// The assigned translator can see the document but not act for the customer
+	for _, route := range []struct {
+		method, path string
+	}{
+		{http.MethodPost, base + "/accept-quote"},
+		{http.MethodGet, base + "/download"},
+		{http.MethodGet, base + "/invoices"},
+		{http.MethodGet, base + "/payments"},
+		{http.MethodGet, base + "/cancellation"},
+		{http.MethodGet, base + "/refunds/1/proof"},
+	} {
+		if status := f.request(t, route.method, route.path, "translator", ""); status != http.StatusForbidden {
+			t.Errorf("%s %s as the translator returned %d, want 403", route.method, route.path, status)
+		}
+	}
+}
+
+func TestOtherCustomerCannotRateDocument(t *testing.T) {
+	f := newFixture(t)
+	body := fmt.Sprintf(`{"document_id":%d,"translator_id":%d,"rating":1}`, f.document.ID, f.document.TranslatorID)
+
+	if status := f.request(t, http.MethodPost, "/api/ratings", "other", body); status != http.StatusNotFound {
+		t.Fatalf("rating another customer's document returned %d, want 404", status)
+	}
+}
+
+func TestRequestsWithoutTokenAreRejected(t *testing.T) {
+	f := newFixture(t)
+	f.tokens["anonymous"] = ""
+
+	path := fmt.Sprintf("/api/documents/%d/discussions", f.document.ID)
+	if status := f.request(t, http.MethodGet, path, "anonymous", ""); status != http.StatusUnauthorized {
+		t.Fatalf("GET %s without a token returned %d, want 401", path, status)
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when a blob does not exist in the store
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores file contents outside of the database, addressed by key
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the blob store configured through the STORAGE_DRIVER environment variable
+func New() (BlobStore, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:     os.Getenv("S3_ENDPOINT"),
+			Region:       os.Getenv("S3_REGION"),
+			Bucket:       os.Getenv("S3_BUCKET"),
+			AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+			SecretKey:    os.Getenv("S3_SECRET_KEY"),
+			UsePathStyle: os.Getenv("S3_USE_PATH_STYLE") == "true",
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// NewKey generates a unique key under prefix that keeps the original file name readable
+func NewKey(prefix, fileName string) string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return path.Join(prefix, hex.EncodeToString(buf), sanitizeFileName(fileName))
+}
+
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// roundTrip checks the BlobStore contract every driver must follow
+func roundTrip(t *testing.T, store BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+	key := NewKey("tests", "report final.docx")
+	content := []byte("translated contents")
+
+	if err := store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "application/octet-stream"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("reading blob: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("Get returned %q, want %q", got, content)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete returned %v, want ErrNotFound", err)
+	}
+
+	// Deleting a missing blob is not an error, cleanup paths rely on it
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing blob: %v", err)
+	}
+}
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, store)
+}
+
+func TestLocalStoreRejectsKeysOutsideRoot(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../escape", "/etc/passwd", "..", "."} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestNewKeyKeepsFileNameSafe(t *testing.T) {
+	key := NewKey("documents", `..\..\évil name.pdf`)
+	if !strings.HasPrefix(key, "documents/") || !strings.HasSuffix(key, "/_vil_name.pdf") {
+		t.Fatalf("unexpected key %q", key)
+	}
+}
+
+// fakeS3 is an in-memory stand-in for the subset of the S3 API the store uses
+type fakeS3 struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.blobs[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.blobs[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.blobs, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3StoreRoundTripPathStyle(t *testing.T) {
+	fake := &fakeS3{blobs: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:     server.URL,
+		Bucket:       "uploads",
+		AccessKey:    "key",
+		SecretKey:    "secret",
+		UsePathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, store)
+}
+
+// TestS3StoreAgainstServer runs against a real S3-compatible server such as MinIO, e.g.
+// S3_TEST_ENDPOINT=http://localhost:9000 S3_TEST_BUCKET=test S3_TEST_ACCESS_KEY=minioadmin S3_TEST_SECRET_KEY=minioadmin
+func TestS3StoreAgainstServer(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:     endpoint,
+		Region:       os.Getenv("S3_TEST_REGION"),
+		Bucket:       os.Getenv("S3_TEST_BUCKET"),
+		AccessKey:    os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey:    os.Getenv("S3_TEST_SECRET_KEY"),
+		UsePathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, store)
+}
@@ -0,0 +1,117 @@
+package textextract
+
+import (
+	"errors"
+	"strings"
+)
+
+// Operations of a DiffLine
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the table of the line diff, about 32 MB
+const maxDiffCells = 4_000_000
+
+// ErrDiffTooLarge is returned when two texts differ in too many lines to compare
+var ErrDiffTooLarge = errors.New("texts are too large to compare")
+
+// DiffLine is one line of a line-by-line comparison
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines compares before and after line by line and returns the lines of both in order,
+// marking the ones that were removed from before or added in after.
+func DiffLines(before, after string) ([]DiffLine, error) {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// The common start and end don't need the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var diff []DiffLine
+	for _, line := range a[:prefix] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	middle, err := diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if err != nil {
+		return nil, err
+	}
+	diff = append(diff, middle...)
+
+	for _, line := range a[len(a)-suffix:] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+	return diff, nil
+}
+
+// diffMiddle diffs the lines using their longest common subsequence
+func diffMiddle(a, b []string) ([]DiffLine, error) {
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return nil, ErrDiffTooLarge
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int32, len(a)+1)
+	for i := range common {
+		common[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return diff, nil
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,142 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	textNamespace = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// extractDOCX reads the text runs of the main document part
+func extractDOCX(data []byte) (string, error) {
+	part, err := readZipPart(data, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+
+	return xmlText(part, func(name xml.Name) bool {
+		return name.Space == wordNamespace && name.Local == "t"
+	}, func(name xml.Name) string {
+		if name.Space != wordNamespace {
+			return ""
+		}
+		switch name.Local {
+		case "p", "br", "cr":
+			return "\n"
+		case "tab":
+			return "\t"
+		}
+		return ""
+	})
+}
+
+// extractODT reads the text of content.xml, which holds the body of an OpenDocument text file
+func extractODT(data []byte) (string, error) {
+	part, err := readZipPart(data, "content.xml")
+	if err != nil {
+		return "", err
+	}
+
+	return xmlText(part, func(name xml.Name) bool {
+		return true
+	}, func(name xml.Name) string {
+		if name.Space != textNamespace {
+			return ""
+		}
+		switch name.Local {
+		case "p", "h", "line-break":
+			return "\n"
+		case "s":
+			return " "
+		case "tab":
+			return "\t"
+		}
+		return ""
+	})
+}
+
+func readZipPart(data []byte, name string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readLimited(r, MaxInflatedSize)
+	}
+
+	return nil, errors.New("missing " + name)
+}
+
+// xmlText collects character data inside elements accepted by isText and inserts
+// the separator returned by separator whenever an element ends
+func xmlText(data []byte, isText func(xml.Name) bool, separator func(xml.Name) string) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var b strings.Builder
+	var stack []xml.Name
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			b.WriteString(separator(t.Name))
+		case xml.CharData:
+			if len(stack) > 0 && isText(stack[len(stack)-1]) {
+				b.Write(t)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// decodeText turns a plain text file into a string, handling UTF-16 files saved by Windows editors
+func decodeText(data []byte) string {
+	if len(data) >= 2 && (data[0] == 0xFF && data[1] == 0xFE || data[0] == 0xFE && data[1] == 0xFF) {
+		littleEndian := data[0] == 0xFF
+		units := make([]uint16, 0, len(data)/2)
+		for i := 2; i+1 < len(data); i += 2 {
+			if littleEndian {
+				units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+			} else {
+				units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+			}
+		}
+		return string(utf16.Decode(units))
+	}
+
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), " ")
+}
@@ -0,0 +1,323 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnreadable is returned for a PDF whose text is drawn with fonts it can't decode, such as
+// Identity-H fonts. Counting their glyph codes as words would give a wrong quote.
+var ErrUnreadable = errors.New("text of the PDF cannot be decoded")
+
+// unreadableShare is the share of string bytes that may be control codes before the fonts of a
+// PDF are taken to use their own encoding
+const unreadableShare = 0.1
+
+var (
+	pdfObjectHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfContentsRef  = regexp.MustCompile(`/Contents\s*(\[[^\]]*\]|\d+\s+\d+\s+R)`)
+	pdfReference    = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+)
+
+// pdfStream is a stream object of a PDF before it is decoded
+type pdfStream struct {
+	number  string
+	dict    []byte
+	content []byte
+}
+
+// extractPDF reads the text of the page content streams and the form XObjects they draw. It
+// handles uncompressed and Flate streams with simple font encodings, which covers the PDFs
+// exported by common word processors. Scanned documents yield no text, and documents with
+// composite (Identity-H) or custom font encodings fail with ErrUnreadable.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data[:min(len(data), 1024)]), []byte("%PDF")) {
+		return "", errors.New("not a PDF file")
+	}
+
+	streams := pdfStreams(data)
+	// Shared by every stream, a file can hold many small streams that each inflate a lot
+	budget := int64(MaxInflatedSize)
+
+	// Page dictionaries are either in the file or packed into compressed object streams
+	dictionaries := [][]byte{data}
+	for _, stream := range streams {
+		if !bytes.Contains(stream.dict, []byte("/ObjStm")) {
+			continue
+		}
+		content, err := pdfDecode(stream, &budget)
+		if err != nil {
+			return "", err
+		}
+		dictionaries = append(dictionaries, content)
+	}
+
+	contents := map[string]bool{}
+	for _, dictionary := range dictionaries {
+		if bytes.Contains(dictionary, []byte("/Identity-H")) || bytes.Contains(dictionary, []byte("/Identity-V")) {
+			return "", ErrUnreadable
+		}
+		for _, ref := range pdfContentsRef.FindAllSubmatch(dictionary, -1) {
+			for _, number := range pdfReference.FindAllSubmatch(ref[1], -1) {
+				contents[string(number[1])] = true
+			}
+		}
+	}
+
+	var text pdfText
+	for _, stream := range streams {
+		// Fonts, images and metadata are streams too, but never hold text to count
+		if !contents[stream.number] && !bytes.Contains(stream.dict, []byte("/Form")) {
+			continue
+		}
+		content, err := pdfDecode(stream, &budget)
+		if errors.Is(err, ErrTooLarge) {
+			return "", err
+		}
+		if err != nil {
+			continue
+		}
+		text.read(content)
+	}
+
+	if text.unreadable > 0 && float64(text.unreadable) > unreadableShare*float64(text.codes) {
+		return "", ErrUnreadable
+	}
+	return text.b.String(), nil
+}
+
+// pdfStreams lists the stream objects of a PDF in file order
+func pdfStreams(data []byte) []pdfStream {
+	var streams []pdfStream
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		dict := rest[:start]
+		number := ""
+		if headers := pdfObjectHeader.FindAllSubmatchIndex(dict, -1); len(headers) > 0 {
+			last := headers[len(headers)-1]
+			number = string(dict[last[2]:last[3]])
+			dict = dict[last[0]:]
+		}
+
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		streams = append(streams, pdfStream{number: number, dict: dict, content: body[:end]})
+		rest = body[end+len("endstream"):]
+	}
+	return streams
+}
+
+// pdfDecode inflates a Flate stream within what is left of budget. Streams with other
+// filters are images and fail.
+func pdfDecode(stream pdfStream, budget *int64) ([]byte, error) {
+	if !bytes.Contains(stream.dict, []byte("/FlateDecode")) {
+		if bytes.Contains(stream.dict, []byte("/Filter")) {
+			return nil, errors.New("unsupported stream filter")
+		}
+		return stream.content, nil
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(stream.content))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// Truncated streams are common, keep whatever could be inflated
+	content, err := readLimited(r, *budget)
+	if errors.Is(err, ErrTooLarge) {
+		return nil, err
+	}
+	*budget -= int64(len(content))
+	return content, nil
+}
+
+// pdfText collects the text shown by content streams, counting the string bytes that no
+// simple font encoding would draw
+type pdfText struct {
+	b          strings.Builder
+	codes      int
+	unreadable int
+}
+
+// show adds a string operand shown by a text operator
+func (t *pdfText) show(s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		t.codes++
+		if (c < 0x20 && c != '\n' && c != '\r' && c != '\t') || (c >= 0x7f && c < 0xa0) {
+			t.unreadable++
+		}
+	}
+	t.b.WriteString(latin1(s))
+}
+
+func (t *pdfText) read(content []byte) {
+	contentStreamText(t, content)
+}
+
+// contentStreamText interprets the operands of Tj, TJ, ' and " operators
+func contentStreamText(t *pdfText, content []byte) {
+	var operands []string
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteralString(content[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			s, n := pdfHexString(content[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '[' || c == ']':
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i < len(content) && (content[i] == '-' || content[i] == '.' || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			// Large negative kerning inside a TJ array stands for a space between words
+			if value, err := strconv.ParseFloat(string(content[start:i]), 64); err == nil && value < -200 {
+				operands = append(operands, " ")
+			}
+		case isPDFOperatorChar(c):
+			start := i
+			for i < len(content) && isPDFOperatorChar(content[i]) {
+				i++
+			}
+			switch string(content[start:i]) {
+			case "Tj", "TJ":
+				t.show(strings.Join(operands, ""))
+			case "'", "\"":
+				t.b.WriteString("\n")
+				t.show(strings.Join(operands, ""))
+			case "Td", "TD", "T*", "Tm", "ET":
+				t.b.WriteString("\n")
+			}
+			operands = operands[:0]
+		default:
+			i++
+		}
+	}
+}
+
+func isPDFOperatorChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '*' || c == '\'' || c == '"'
+}
+
+// pdfLiteralString decodes a (...) string and returns it with the number of bytes consumed
+func pdfLiteralString(data []byte) (string, int) {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				b.WriteByte(c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b.String(), i + 1
+			}
+			b.WriteByte(c)
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch e := data[i]; e {
+			case 'n', 'r':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					j := 0
+					for ; j < 3 && i+j < len(data) && data[i+j] >= '0' && data[i+j] <= '7'; j++ {
+						value = value*8 + int(data[i+j]-'0')
+					}
+					i += j - 1
+					b.WriteByte(byte(value))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), len(data)
+}
+
+// pdfHexString decodes a <...> string and returns it with the number of bytes consumed
+func pdfHexString(data []byte) (string, int) {
+	end := bytes.IndexByte(data, '>')
+	if end < 0 {
+		return "", len(data)
+	}
+
+	var digits []byte
+	for _, c := range data[1:end] {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	decoded := make([]byte, len(digits)/2)
+	for i := range decoded {
+		decoded[i] = hexValue(digits[2*i])<<4 | hexValue(digits[2*i+1])
+	}
+	return string(decoded), end + 1
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// latin1 maps single byte font codes to runes, dropping control characters
+func latin1(s string) string {
+	runes := make([]rune, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 && c != '\n' && c != '\t' {
+			continue
+		}
+		runes = append(runes, rune(c))
+	}
+	return string(runes)
+}
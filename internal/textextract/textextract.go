@@ -0,0 +1,80 @@
+// Package textextract pulls plain text out of uploaded documents so they can be
+// counted and compared. Extraction is best-effort: layout is not preserved.
+package textextract
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ErrUnsupported is returned for file types text cannot be extracted from
+var ErrUnsupported = errors.New("unsupported file type")
+
+// MaxInflatedSize caps how much a compressed file may expand while its text is read, so a
+// small upload can't inflate to gigabytes in memory
+const MaxInflatedSize = 64 << 20
+
+// ErrTooLarge is returned when a compressed file expands beyond MaxInflatedSize
+var ErrTooLarge = errors.New("file expands beyond the size text is extracted from")
+
+// readLimited reads r to the end, failing with ErrTooLarge past limit bytes. What was read
+// before another error is returned with it.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, err
+}
+
+// Supported reports whether text can be extracted from a file with this name
+func Supported(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".txt", ".docx", ".odt", ".pdf":
+		return true
+	default:
+		return false
+	}
+}
+
+// Extract returns the text content of a .txt, .docx, .odt or .pdf file
+func Extract(fileName string, data []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".txt":
+		return decodeText(data), nil
+	case ".docx":
+		return extractDOCX(data)
+	case ".odt":
+		return extractODT(data)
+	case ".pdf":
+		return extractPDF(data)
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// CountWords counts words in text. Scripts written without spaces (Chinese,
+// Japanese) are counted per character, which is how translators bill them.
+func CountWords(text string) int {
+	count := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			count++
+			inWord = false
+		case unicode.IsSpace(r):
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				count++
+				inWord = true
+			}
+		}
+		// Other punctuation neither starts nor ends a word, so "e-mail" counts once
+	}
+	return count
+}
@@ -0,0 +1,229 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// zeros writes n zero bytes to w without holding them in memory at once
+func zeros(t *testing.T, w interface{ Write([]byte) (int, error) }, n int) {
+	t.Helper()
+	chunk := make([]byte, 1<<20)
+	for n > 0 {
+		size := min(n, len(chunk))
+		if _, err := w.Write(chunk[:size]); err != nil {
+			t.Fatal(err)
+		}
+		n -= size
+	}
+}
+
+func TestExtractDOCXRejectsInflatedParts(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	part, err := archive.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zeros(t, part, MaxInflatedSize+1)
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Extract("bomb.docx", buf.Bytes()); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Extract returned %v, want ErrTooLarge", err)
+	}
+}
+
+// flate compresses data the way PDF writers do
+func flate(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pdfFile builds a one page PDF drawing the Flate compressed content of object 2. The other
+// objects follow from number 3 on, each with its dictionary and stream.
+func pdfFile(content []byte, objects ...[2]string) []byte {
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Page /Contents 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Filter /FlateDecode >>\nstream\n")
+	pdf.Write(content)
+	pdf.WriteString("\nendstream\nendobj\n")
+	for i, object := range objects {
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nstream\n%s\nendstream\nendobj\n", i+3, object[0], object[1])
+	}
+	pdf.WriteString("%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestExtractPDFRejectsInflatedStreams(t *testing.T) {
+	var stream bytes.Buffer
+	w := zlib.NewWriter(&stream)
+	zeros(t, w, MaxInflatedSize+1)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Extract("bomb.pdf", pdfFile(stream.Bytes())); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Extract returned %v, want ErrTooLarge", err)
+	}
+}
+
+func TestExtractPDFKeepsText(t *testing.T) {
+	text, err := Extract("hello.pdf", pdfFile(flate(t, []byte("BT /F1 12 Tf (Hello world) Tj ET"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountWords(text) != 2 {
+		t.Fatalf("Extract returned %q, want two words", text)
+	}
+}
+
+func TestExtractPDFSkipsStreamsThatAreNotContent(t *testing.T) {
+	// A font program and an image whose bytes happen to read as text operators
+	data := pdfFile(flate(t, []byte("BT (Hello world) Tj ET")),
+		[2]string{"<< /Length1 40 >>", "BT (glyph outlines of the embedded font) Tj ET"},
+		[2]string{"<< /Type /XObject /Subtype /Image >>", "BT (pixels) Tj ET"},
+		[2]string{"<< /Type /XObject /Subtype /Form >>", "BT (Page footer) Tj ET"},
+	)
+
+	text, err := Extract("fonts.pdf", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountWords(text) != 4 {
+		t.Fatalf("Extract returned %q, want the page and its footer only", text)
+	}
+}
+
+func TestExtractPDFReadsPagesInObjectStreams(t *testing.T) {
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.5\n1 0 obj\n<< /Type /ObjStm /Filter /FlateDecode >>\nstream\n")
+	pdf.Write(flate(t, []byte("3 0 << /Type /Page /Contents [2 0 R] >>")))
+	pdf.WriteString("\nendstream\nendobj\n2 0 obj\n<< /Filter /FlateDecode >>\nstream\n")
+	pdf.Write(flate(t, []byte("BT (Hello world) Tj ET")))
+	pdf.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	text, err := Extract("compact.pdf", pdf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountWords(text) != 2 {
+		t.Fatalf("Extract returned %q, want two words", text)
+	}
+}
+
+func TestExtractPDFRejectsFontsItCannotDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"identity font", pdfFile(flate(t, []byte("BT /F1 12 Tf <002B00480052> Tj ET")),
+			[2]string{"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H >>", ""})},
+		{"two byte glyph codes", pdfFile(flate(t, []byte("BT /F1 12 Tf <002B0048004F004F0052> Tj ET")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text, err := Extract("subset.pdf", tt.data); !errors.Is(err, ErrUnreadable) {
+				t.Fatalf("Extract returned %q, %v, want ErrUnreadable", text, err)
+			}
+		})
+	}
+}
+
+func TestExtractPDFDecodesHexStringsAndKerning(t *testing.T) {
+	text, err := Extract("hex.pdf", pdfFile(flate(t, []byte("BT <48656C6C6F20776F726C64> Tj ET BT [(Ann) -20 (ual) -300 (report)] TJ ET"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountWords(text) != 4 {
+		t.Fatalf("Extract returned %q, want four words", text)
+	}
+}
+
+// zipFile builds an archive holding a single part
+func zipFile(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	part, err := archive.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDOCX(t *testing.T) {
+	data := zipFile(t, "word/document.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Annual</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Revenue</w:t><w:tab/><w:t>grew</w:t></w:r></w:p>
+<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>Outlook</w:t></w:r></w:p>
+</w:body></w:document>`)
+
+	text, err := Extract("report.docx", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Annual report\nRevenue\tgrew\nOutlook\n"; text != want {
+		t.Fatalf("Extract returned %q, want %q", text, want)
+	}
+}
+
+func TestExtractODT(t *testing.T) {
+	data := zipFile(t, "content.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:text>
+<text:h>Annual report</text:h><text:p>Revenue<text:s/>grew <text:span>fast</text:span></text:p>
+</office:text></office:body></office:document-content>`)
+
+	text, err := Extract("report.odt", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountWords(text) != 5 {
+		t.Fatalf("Extract returned %q, want five words", text)
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text  string
+		words int
+	}{
+		{"", 0},
+		{"  \n\t ", 0},
+		{"Hello world", 2},
+		{"Hello,world", 1},
+		{"e-mail address", 2},
+		{"It's 2024 - the year", 4},
+		{"Selamat pagi, dunia!", 3},
+		{"Ünïcödé wörds", 2},
+		{"翻译文档", 4},
+		{"これはテスト", 6},
+		{"PDF 文件", 3},
+		{"— … —", 0},
+	}
+	for _, tt := range tests {
+		if got := CountWords(tt.text); got != tt.words {
+			t.Errorf("CountWords(%q) = %d, want %d", tt.text, got, tt.words)
+		}
+	}
+}